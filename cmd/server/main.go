@@ -1,14 +1,32 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	"job-portal-api/internal/events"
 	"job-portal-api/internal/handlers"
+	"job-portal-api/internal/middleware"
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/policy"
 	"job-portal-api/internal/repository"
 	"job-portal-api/internal/routes"
 	"job-portal-api/internal/services"
 	"job-portal-api/pkg/cloudinary"
+	"job-portal-api/pkg/mailer"
+	"job-portal-api/pkg/oidc"
+	"job-portal-api/pkg/password"
+	"job-portal-api/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -24,6 +42,11 @@ func main() {
 		log.Fatal("POSTGRES_DB environment variable is not set")
 	}
 
+	// Load the JWT signing and verification keys
+	if err := utils.LoadJWTKeys(); err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+
 	// Run migrations
 	if err := repository.RunMigrations(dsn); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
@@ -38,6 +61,10 @@ func main() {
 	log.Println("Database connected successfully")
 
 	r := gin.Default()
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	r.Use(middleware.RequestContext())
 
 	// Initialize Cloudinary
 	cldService, err := cloudinary.NewService()
@@ -45,21 +72,73 @@ func main() {
 		log.Fatalf("Failed to initialize Cloudinary service: %v", err)
 	}
 
+	bus := events.NewBus()
+
+	// Initialize mailer; messages are sent in the background
+	transport, err := mailer.NewFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+	mail := mailer.NewQueue(transport, 1000)
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(pool)
 	jobRepo := repository.NewJobRepository(pool)
+	applicationRepo := repository.NewApplicationRepository(pool)
+	companyRepo := repository.NewCompanyRepository(pool)
+	roleRepo := repository.NewRoleRepository(pool)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(pool)
+	sessionRepo := repository.NewSessionRepository(pool)
+	emailVerificationRepo := repository.NewEmailVerificationRepository(pool)
+	rateLimitRepo := repository.NewRateLimitRepository(pool)
+	mfaRepo := repository.NewMFARepository(pool)
+	identityRepo := repository.NewIdentityRepository(pool)
+	apiKeyRepo := repository.NewAPIKeyRepository(pool)
+	impersonationRepo := repository.NewImpersonationRepository(pool)
+	auditRepo := repository.NewAuditRepository(pool)
+
+	// Load role permissions
+	permissions, err := roleRepo.GetRolePermissions(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to load role permissions: %v", err)
+	}
+	policy.Load(permissions)
 
 	// Initialize services
 	appService := services.NewAppService(pool)
-	authService := services.NewAuthService(userRepo)
-	userService := services.NewUserService(userRepo, jobRepo, cldService)
-	jobService := services.NewJobService(jobRepo, cldService)
+	auditService := services.NewAuditService(auditRepo)
+	rateLimiter := services.NewRateLimiter(rateLimitRepo)
+	verificationService := services.NewEmailVerificationService(emailVerificationRepo, userRepo, mail, rateLimiter, bus)
+	mfaService := services.NewMFAService(mfaRepo, userRepo, rateLimiter, mfaOptions(), auditService)
+	authService := services.NewAuthService(userRepo, refreshTokenRepo, sessionRepo, verificationService, mail, rateLimiter, mfaService, passwordPolicy(), passwordHasher(), passwordResetOptions(), loginLockoutOptions(), bus, auditService)
+	oidcService := services.NewOIDCService(oidcProviders(), identityRepo, userRepo, authService)
+	userService := services.NewUserService(userRepo, jobRepo, cldService, verificationService, bus, auditService)
+	jobService := services.NewJobService(jobRepo, companyRepo, cldService, bus, auditService)
+	applicationService := services.NewApplicationService(applicationRepo, jobRepo, companyRepo, userRepo, cldService, mail)
+	companyService := services.NewCompanyService(companyRepo, userRepo, cldService, mail, companyInvitationOptions(), auditService)
+	sessionService := services.NewSessionService(sessionRepo, auditService)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, companyRepo, auditService)
+	impersonationService := services.NewImpersonationService(impersonationRepo, userRepo, sessionRepo, auditService)
+
+	// Reject access tokens of revoked sessions
+	middleware.SetSessionValidator(sessionService)
+	middleware.SetAPIKeyValidator(apiKeyService)
+	middleware.SetImpersonationAuditor(impersonationService)
+	bus.Subscribe(events.UserChangedEvent, sessionService.HandleUserChanged)
 
 	// Initialize handlers
 	appHandler := handlers.NewAppHandler(appService)
-	authHandler := handlers.NewAuthHandler(authService)
+	authHandler := handlers.NewAuthHandler(authService, verificationService)
 	userHandler := handlers.NewUserHandler(userService)
 	jobHandler := handlers.NewJobHandler(jobService)
+	applicationHandler := handlers.NewApplicationHandler(applicationService)
+	companyHandler := handlers.NewCompanyHandler(companyService)
+	sessionHandler := handlers.NewSessionHandler(sessionService)
+	mfaHandler := handlers.NewMFAHandler(mfaService)
+	oidcHandler := handlers.NewOIDCHandler(oidcService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	impersonationHandler := handlers.NewImpersonationHandler(impersonationService)
+	auditHandler := handlers.NewAuditHandler(auditService)
 
 	// Setup routes
 	api := r.Group("/api")
@@ -67,14 +146,260 @@ func main() {
 	routes.RegisterAuthRoutes(api, authHandler)
 	routes.RegisterUserRoutes(api, userHandler)
 	routes.RegisterJobRoutes(api, jobHandler)
+	routes.RegisterApplicationRoutes(api, applicationHandler)
+	routes.RegisterCompanyRoutes(api, companyHandler)
+	routes.RegisterSessionRoutes(api, sessionHandler)
+	routes.RegisterMFARoutes(api, mfaHandler)
+	routes.RegisterOIDCRoutes(api, oidcHandler)
+	routes.RegisterAPIKeyRoutes(api, apiKeyHandler)
+	routes.RegisterImpersonationRoutes(api, impersonationHandler)
+	routes.RegisterAuditRoutes(api, auditHandler)
+	routes.RegisterWellKnownRoutes(&r.RouterGroup, authHandler)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Start background workers. They get their own context, which is only
+	// cancelled once the server has finished its in-flight requests, so that
+	// mail those requests queue is still sent.
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	var workers sync.WaitGroup
+	expiryInterval := durationFromEnv("JOB_EXPIRY_INTERVAL", time.Minute)
+	schedulerInterval := durationFromEnv("JOB_SCHEDULER_INTERVAL", 30*time.Second)
+	workers.Add(4)
+	go func() {
+		defer workers.Done()
+		jobService.RunExpiryWorker(workerCtx, expiryInterval)
+	}()
+	go func() {
+		defer workers.Done()
+		jobService.RunPublishScheduler(workerCtx, schedulerInterval)
+	}()
+	go func() {
+		defer workers.Done()
+		mail.Run(workerCtx, 2)
+	}()
+	go func() {
+		defer workers.Done()
+		rateLimiter.RunCleanup(workerCtx, time.Hour)
+	}()
 
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	if err := r.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: r,
 	}
 
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down server...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server forced to shut down: %v", err)
+	}
+
+	// Cancelling interrupts a worker run in progress; its work is picked up
+	// again by the next run, here or on another instance. The mail queue sends
+	// what is left before returning. Wait for all of them before the pool is
+	// closed.
+	stopWorkers()
+	workers.Wait()
+	log.Println("Server stopped")
+}
+
+// durationFromEnv reads a time.Duration such as "30s" from the environment,
+// falling back to def when the variable is unset.
+func durationFromEnv(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Fatalf("Invalid %s: %q", key, v)
+	}
+	return d
+}
+
+// intFromEnv reads a positive integer from the environment, falling back to
+// def when the variable is unset.
+func intFromEnv(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		log.Fatalf("Invalid %s: %q", key, v)
+	}
+	return n
+}
+
+// passwordPolicy reads PASSWORD_MIN_LENGTH (default 8), PASSWORD_MAX_LENGTH
+// (default 64), PASSWORD_REQUIRED_CLASSES, a comma separated list of lower,
+// upper, digit and symbol (default "lower,upper,digit", or "none"), and
+// BREACHED_PASSWORDS_DIR, a directory of breached password range files.
+func passwordPolicy() *password.Policy {
+	p := &password.Policy{
+		MinLength:        intFromEnv("PASSWORD_MIN_LENGTH", 8),
+		MaxLength:        intFromEnv("PASSWORD_MAX_LENGTH", 64),
+		DisallowIdentity: true,
+	}
+	if p.MaxLength < p.MinLength {
+		log.Fatal("PASSWORD_MAX_LENGTH must not be less than PASSWORD_MIN_LENGTH")
+	}
+
+	classes := os.Getenv("PASSWORD_REQUIRED_CLASSES")
+	if classes == "" {
+		classes = "lower,upper,digit"
+	}
+	if classes != "none" {
+		for _, c := range strings.Split(classes, ",") {
+			class := password.CharClass(strings.TrimSpace(c))
+			if !class.IsValid() {
+				log.Fatalf("Invalid character class in PASSWORD_REQUIRED_CLASSES: %q", class)
+			}
+			p.RequiredClasses = append(p.RequiredClasses, class)
+		}
+	}
+
+	if dir := os.Getenv("BREACHED_PASSWORDS_DIR"); dir != "" {
+		breached, err := password.OpenBreachedList(dir)
+		if err != nil {
+			log.Fatalf("Failed to load breached passwords: %v", err)
+		}
+		p.Breached = breached
+	}
+	return p
+}
+
+// passwordHasher reads the Argon2id cost of new password hashes from
+// PASSWORD_ARGON2_MEMORY (KiB), PASSWORD_ARGON2_ITERATIONS and
+// PASSWORD_ARGON2_PARALLELISM. Changing them makes existing hashes get
+// replaced as users log in. PASSWORD_ARGON2_CONCURRENCY, by default the
+// number of CPUs, limits how many hashes are computed at once.
+func passwordHasher() *password.Hasher {
+	params := password.DefaultArgon2idParams
+	params.Memory = uint32(intFromEnv("PASSWORD_ARGON2_MEMORY", int(params.Memory)))
+	params.Iterations = uint32(intFromEnv("PASSWORD_ARGON2_ITERATIONS", int(params.Iterations)))
+	parallelism := intFromEnv("PASSWORD_ARGON2_PARALLELISM", int(params.Parallelism))
+	if parallelism > 255 {
+		log.Fatalf("Invalid PASSWORD_ARGON2_PARALLELISM: %d", parallelism)
+	}
+	params.Parallelism = uint8(parallelism)
+	concurrency := intFromEnv("PASSWORD_ARGON2_CONCURRENCY", runtime.NumCPU())
+	return password.NewHasher(params, concurrency)
+}
+
+// passwordResetOptions reads PASSWORD_RESET_MODE ("code", the default, or
+// "link"), the PASSWORD_RESET_CODE_KEY that codes are hashed with and, for
+// links, the PASSWORD_RESET_URL they point to.
+func passwordResetOptions() services.PasswordResetOptions {
+	switch mode := os.Getenv("PASSWORD_RESET_MODE"); mode {
+	case "", "code":
+		key := os.Getenv("PASSWORD_RESET_CODE_KEY")
+		if len(key) < 32 {
+			log.Fatal("PASSWORD_RESET_CODE_KEY must be set to at least 32 random characters when PASSWORD_RESET_MODE is code")
+		}
+		return services.PasswordResetOptions{CodeKey: []byte(key)}
+	case "link":
+		url := os.Getenv("PASSWORD_RESET_URL")
+		if url == "" {
+			log.Fatal("PASSWORD_RESET_URL must be set when PASSWORD_RESET_MODE is link")
+		}
+		return services.PasswordResetOptions{LinkTokens: true, LinkURL: url}
+	default:
+		log.Fatalf("Invalid PASSWORD_RESET_MODE: %q", mode)
+		return services.PasswordResetOptions{}
+	}
+}
+
+// loginLockoutOptions reads ACCOUNT_UNLOCK_URL, the page that unlock links in
+// lockout emails open. Without it the emails contain a token to submit.
+func loginLockoutOptions() services.LoginLockoutOptions {
+	return services.LoginLockoutOptions{UnlockURL: os.Getenv("ACCOUNT_UNLOCK_URL")}
+}
+
+// trustedProxies reads TRUSTED_PROXIES, a comma separated list of the IPs or
+// CIDR ranges of reverse proxies in front of the API. Only they may set the
+// client IP through X-Forwarded-For; without any, the IP is the address the
+// connection comes from, so clients can't pick the IP that rate limits and
+// the audit log see.
+func trustedProxies() []string {
+	var proxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
+
+// companyInvitationOptions reads COMPANY_INVITATION_URL, the page that links
+// in invitation emails open. Without it the emails contain a token to submit.
+func companyInvitationOptions() services.InvitationOptions {
+	return services.InvitationOptions{AcceptURL: os.Getenv("COMPANY_INVITATION_URL")}
+}
+
+// mfaOptions reads MFA_ISSUER, the name shown in authenticator apps, and
+// MFA_REQUIRED_ROLES, a comma separated list of roles such as "admin" that
+// must use a second factor.
+func mfaOptions() services.MFAOptions {
+	options := services.MFAOptions{Issuer: os.Getenv("MFA_ISSUER")}
+	if options.Issuer == "" {
+		options.Issuer = "Job Portal"
+	}
+
+	for _, r := range strings.Split(os.Getenv("MFA_REQUIRED_ROLES"), ",") {
+		role := models.Role(strings.TrimSpace(r))
+		if role == "" {
+			continue
+		}
+		if !role.IsValid() {
+			log.Fatalf("Invalid role in MFA_REQUIRED_ROLES: %q", role)
+		}
+		options.RequiredRoles = append(options.RequiredRoles, role)
+	}
+	return options
+}
+
+// oidcProviders reads the OpenID providers listed in OIDC_PROVIDERS, a comma
+// separated list of names. Each provider NAME is configured with
+// OIDC_NAME_ISSUER, OIDC_NAME_CLIENT_ID, OIDC_NAME_REDIRECT_URL and optionally
+// OIDC_NAME_CLIENT_SECRET and OIDC_NAME_SCOPES.
+func oidcProviders() map[string]*oidc.Provider {
+	providers := make(map[string]*oidc.Provider)
+	for _, n := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name := strings.ToLower(strings.TrimSpace(n))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		config := oidc.Config{
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if config.Issuer == "" || config.ClientID == "" || config.RedirectURL == "" {
+			log.Fatalf("%sISSUER, %sCLIENT_ID and %sREDIRECT_URL must be set", prefix, prefix, prefix)
+		}
+		providers[name] = oidc.NewProvider(config)
+	}
+	return providers
 }
@@ -0,0 +1,78 @@
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"job-portal-api/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Event is something that happened in the system that other parts may react to.
+type Event interface {
+	Name() string
+}
+
+// Handler reacts to a published event. Handlers run synchronously on the
+// publisher's goroutine, so slow work should be handed off.
+type Handler func(ctx context.Context, event Event)
+
+const JobPublishedEvent = "job.published"
+
+// JobPublished is emitted whenever a job becomes publicly visible, whether
+// published by hand or by the scheduler.
+type JobPublished struct {
+	Job         models.Job
+	PublishedAt time.Time
+}
+
+func (JobPublished) Name() string { return JobPublishedEvent }
+
+const UserChangedEvent = "user.changed"
+
+// UserChanged is emitted when a user's role or email verification changes,
+// or their password changes and their sessions are revoked, so that cached
+// authorization data can be dropped.
+type UserChanged struct {
+	UserID uuid.UUID
+}
+
+func (UserChanged) Name() string { return UserChangedEvent }
+
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe registers a handler for events with the given name.
+func (b *Bus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], handler)
+}
+
+// Publish delivers the event to every subscribed handler. A panicking handler
+// is logged and does not prevent the others from running.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	b.mu.RLock()
+	handlers := b.handlers[event.Name()]
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("Event handler for %s panicked: %v", event.Name(), r)
+				}
+			}()
+			handler(ctx, event)
+		}()
+	}
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	service *services.APIKeyService
+}
+
+func NewAPIKeyHandler(service *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string               `json:"name" binding:"required,max=100"`
+	Scopes    []models.APIKeyScope `json:"scopes" binding:"required"`
+	ExpiresAt *time.Time           `json:"expires_at"`
+	CompanyID *uuid.UUID           `json:"company_id"`
+}
+
+// GetAPIKeys lists the request user's personal keys, or a company's keys when
+// company_id is given.
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	var companyID *uuid.UUID
+	if v := c.Query("company_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+			return
+		}
+		companyID = &id
+	}
+
+	keys, err := h.service.GetAPIKeys(c.Request.Context(), companyID, getRequestUser(c))
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key := &models.APIKey{
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+		CompanyID: req.CompanyID,
+	}
+	rawKey, err := h.service.CreateAPIKey(c.Request.Context(), key, getRequestUser(c))
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+
+	// The key can't be shown again; only its prefix is stored in plaintext
+	c.JSON(http.StatusCreated, gin.H{"api_key": key, "key": rawKey})
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	if err := h.service.RevokeAPIKey(c.Request.Context(), id, getRequestUser(c)); err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
+
+func respondAPIKeyError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "name is required", "at least one scope is required", "invalid scope",
+		"invalid scope for a company key", "expiry must be in the future":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case "unauthorized to manage company api keys":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case "api key not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
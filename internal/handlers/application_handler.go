@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/services"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ApplicationHandler struct {
+	service *services.ApplicationService
+}
+
+func NewApplicationHandler(service *services.ApplicationService) *ApplicationHandler {
+	return &ApplicationHandler{service: service}
+}
+
+// maxResumeSize caps uploaded résumés at 5 MB.
+const maxResumeSize = 5 << 20
+
+// resumeTypes maps the accepted résumé extensions to the content types
+// http.DetectContentType reports for them. DOC files are OLE containers
+// without a sniffable signature, DOCX files are ZIP archives.
+var resumeTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".doc":  "application/octet-stream",
+	".docx": "application/zip",
+}
+
+// checkResume makes sure an uploaded résumé is a PDF, DOC or DOCX file of
+// acceptable size and returns its extension.
+func checkResume(header *multipart.FileHeader, file multipart.File) (string, error) {
+	if header.Size > maxResumeSize {
+		return "", fmt.Errorf("resume must not be larger than %d MB", maxResumeSize>>20)
+	}
+
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+	want, ok := resumeTypes[ext]
+	if !ok {
+		return "", errors.New("resume must be a PDF, DOC or DOCX file")
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", errors.New("resume must be a PDF, DOC or DOCX file")
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	if http.DetectContentType(head[:n]) != want {
+		return "", errors.New("resume must be a PDF, DOC or DOCX file")
+	}
+	return ext, nil
+}
+
+func (h *ApplicationHandler) Apply(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	userIdStr := c.GetString("user_id")
+	userID, err := uuid.Parse(userIdStr)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	// Leave some room for the other form fields
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxResumeSize+1<<20)
+	if err := c.Request.ParseMultipartForm(maxResumeSize + 1<<20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("resume must not be larger than %d MB", maxResumeSize>>20)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid form data"})
+		return
+	}
+
+	app := models.Application{
+		JobID:       jobID,
+		UserID:      userID,
+		CoverLetter: c.PostForm("cover_letter"),
+	}
+
+	var file multipart.File
+	var ext string
+
+	fileHeader, err := c.FormFile("resume")
+	if err == nil {
+		f, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
+			return
+		}
+		defer f.Close()
+
+		if ext, err = checkResume(fileHeader, f); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		file = f
+	}
+
+	createdApp, err := h.service.Apply(c.Request.Context(), &app, file, ext)
+	if err != nil {
+		switch err.Error() {
+		case "you have already applied to this job":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case "you cannot apply to your own job", "this job is not accepting applications":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "company api keys can't apply to jobs":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case "job not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, createdApp)
+}
+
+func (h *ApplicationHandler) GetApplicationsByJob(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	requestUser := getRequestUser(c)
+
+	apps, err := h.service.GetApplicationsByJob(c.Request.Context(), jobID, requestUser)
+	if err != nil {
+		switch err.Error() {
+		case "unauthorized to view applications for this job":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case "job not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, apps)
+}
+
+func (h *ApplicationHandler) GetMyApplications(c *gin.Context) {
+	userIdStr := c.GetString("user_id")
+	userID, err := uuid.Parse(userIdStr)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	apps, err := h.service.GetApplicationsByUser(c.Request.Context(), userID)
+	if err != nil {
+		if err.Error() == "company api keys can't list applications of their creator" {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, apps)
+}
+
+func (h *ApplicationHandler) UpdateStatus(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid application ID"})
+		return
+	}
+
+	var req struct {
+		Status string `json:"status" binding:"required,oneof=screening interview offer hired rejected withdrawn"`
+		Note   string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	requestUser := getRequestUser(c)
+
+	app, err := h.service.UpdateStatus(c.Request.Context(), id, models.ApplicationStatus(req.Status), req.Note, requestUser)
+	if err != nil {
+		switch {
+		case err.Error() == "application not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case err.Error() == "unauthorized to update this application":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "invalid status transition"),
+			err.Error() == "application status has changed, please retry":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, app)
+}
+
+func (h *ApplicationHandler) GetStatusHistory(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid application ID"})
+		return
+	}
+
+	requestUser := getRequestUser(c)
+
+	history, err := h.service.GetStatusHistory(c.Request.Context(), id, requestUser)
+	if err != nil {
+		switch err.Error() {
+		case "application not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "unauthorized to view this application":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+func NewAuditHandler(service *services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// GetEvents lists audit events, newest first. An action filter such as
+// "user" also matches "user.login" and the other user actions.
+func (h *AuditHandler) GetEvents(c *gin.Context) {
+	filter := models.AuditFilter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		RequestID:  c.Query("request_id"),
+	}
+
+	if v := c.Query("actor_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid actor ID"})
+			return
+		}
+		filter.ActorID = &id
+	}
+	if v := c.Query("target_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid target ID"})
+			return
+		}
+		filter.TargetID = &id
+	}
+
+	var err error
+	if filter.From, err = parseOptionalTime(c.Query("from")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be an RFC 3339 timestamp"})
+		return
+	}
+	if filter.To, err = parseOptionalTime(c.Query("to")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be an RFC 3339 timestamp"})
+		return
+	}
+
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Limit = limit
+	filter.Offset = offset
+
+	events, err := h.service.GetEvents(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit events"})
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
@@ -1,26 +1,34 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
+	"job-portal-api/internal/models"
 	"job-portal-api/internal/services"
+	"job-portal-api/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 type AuthHandler struct {
-	authService *services.AuthService
+	authService         *services.AuthService
+	verificationService *services.EmailVerificationService
 }
 
-func NewAuthHandler(authService *services.AuthService) *AuthHandler {
-	return &AuthHandler{authService: authService}
+func NewAuthHandler(authService *services.AuthService, verificationService *services.EmailVerificationService) *AuthHandler {
+	return &AuthHandler{authService: authService, verificationService: verificationService}
 }
 
 type RegisterRequest struct {
 	Username string `json:"username" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
+	Role     string `json:"role" binding:"omitempty,oneof=candidate employer"`
 }
 
 func (h *AuthHandler) Register(c *gin.Context) {
@@ -30,8 +38,11 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	user, err := h.authService.Register(c.Request.Context(), req.Username, req.Email, req.Password)
+	user, err := h.authService.Register(c.Request.Context(), req.Username, req.Email, req.Password, models.Role(req.Role))
 	if err != nil {
+		if respondPasswordPolicyError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -42,6 +53,7 @@ func (h *AuthHandler) Register(c *gin.Context) {
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	Device   string `json:"device" binding:"max=100"`
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
@@ -51,13 +63,147 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, user, err := h.authService.Login(c.Request.Context(), req.Email, req.Password)
+	client := &models.Session{
+		Device:    req.Device,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	result, err := h.authService.Login(c.Request.Context(), req.Email, req.Password, client)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		var blocked *services.LoginBlockedError
+		if errors.As(err, &blocked) {
+			retryAfter := int(math.Ceil(time.Until(blocked.RetryAt).Seconds()))
+			c.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+		}
+
+		switch err.Error() {
+		case "invalid credentials":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case "too many requests":
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "retry_at": blocked.RetryAt})
+		case "account locked":
+			c.JSON(http.StatusLocked, gin.H{"error": err.Error(), "locked_until": blocked.RetryAt})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	if result.Challenge != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required":        true,
+			"mfa_token":           result.Challenge.Token,
+			"expires_at":          result.Challenge.ExpiresAt,
+			"enrollment_required": result.Challenge.EnrollmentRequired,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": result.Tokens.AccessToken, "refresh_token": result.Tokens.RefreshToken, "user": result.User})
+}
+
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required,max=20"`
+}
+
+// VerifyMFA is the second step of a login that returned mfa_required.
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	client := &models.Session{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	result, err := h.authService.VerifyMFA(c.Request.Context(), req.MFAToken, req.Code, client)
+	if err != nil {
+		switch err.Error() {
+		case "invalid mfa token", "invalid code", "mfa not enabled", "mfa enrollment not started", "mfa already enabled":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case "too many requests":
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	response := gin.H{"token": result.Tokens.AccessToken, "refresh_token": result.Tokens.RefreshToken, "user": result.User}
+	if result.RecoveryCodes != nil {
+		response["recovery_codes"] = result.RecoveryCodes
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// StartMFAEnrollment lets a user whose login requires a second factor set
+// one up using the mfa_token of the login.
+func (h *AuthHandler) StartMFAEnrollment(c *gin.Context) {
+	var req struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	enrollment, err := h.authService.StartMFAEnrollment(c.Request.Context(), req.MFAToken)
+	if err != nil {
+		switch err.Error() {
+		case "invalid mfa token":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case "mfa already enabled":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.authService.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		switch err.Error() {
+		case "invalid refresh token", "refresh token expired", "refresh token reuse detected", "user not found":
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authService.Logout(c.Request.Context(), req.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token, "user": user})
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
 
 func (h *AuthHandler) ForgotPassword(c *gin.Context) {
@@ -69,28 +215,41 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	token, err := h.authService.ForgotPassword(c.Request.Context(), req.Email)
-	if err != nil {
+	if err := h.authService.ForgotPassword(c.Request.Context(), req.Email, c.ClientIP()); err != nil {
+		if err.Error() == "too many requests" {
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Password reset token generated", "token": token})
+	c.JSON(http.StatusOK, gin.H{"message": "If an account exists for this email, a password reset message has been sent"})
 }
 
 func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	var req struct {
 		Email       string `json:"email" binding:"required,email"`
-		NewPassword string `json:"new_password" binding:"required,min=6"`
-		Token       string `json:"token" binding:"required,len=6"`
+		NewPassword string `json:"new_password" binding:"required"`
+		Token       string `json:"token" binding:"required,max=128"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if err := h.authService.ResetPassword(c.Request.Context(), req.Email, req.NewPassword, req.Token); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.authService.ResetPassword(c.Request.Context(), req.Email, req.NewPassword, req.Token, c.ClientIP()); err != nil {
+		if respondPasswordPolicyError(c, err) {
+			return
+		}
+		switch err.Error() {
+		case "invalid or expired token":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "too many requests":
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
@@ -100,7 +259,7 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	var req struct {
 		CurrentPassword string `json:"current_password" binding:"required"`
-		NewPassword     string `json:"new_password" binding:"required,min=6"`
+		NewPassword     string `json:"new_password" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -120,6 +279,9 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	}
 
 	if err := h.authService.ChangePassword(c.Request.Context(), userID, req.CurrentPassword, req.NewPassword); err != nil {
+		if respondPasswordPolicyError(c, err) {
+			return
+		}
 		status := http.StatusInternalServerError
 		if err.Error() == "incorrect current password" {
 			status = http.StatusBadRequest
@@ -140,7 +302,7 @@ func (h *AuthHandler) ChangeUserPassword(c *gin.Context) {
 	}
 
 	var req struct {
-		NewPassword string `json:"new_password" binding:"required,min=6"`
+		NewPassword string `json:"new_password" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -150,9 +312,108 @@ func (h *AuthHandler) ChangeUserPassword(c *gin.Context) {
 	// Admin check is assumed to be done by middleware for this route
 
 	if err := h.authService.ChangeUserPassword(c.Request.Context(), targetUserID, req.NewPassword); err != nil {
+		if respondPasswordPolicyError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "User password changed successfully"})
 }
+
+// UnlockAccount lifts a lockout using the token from the lockout email.
+func (h *AuthHandler) UnlockAccount(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required,max=128"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.authService.UnlockAccount(c.Request.Context(), req.Token, c.ClientIP()); err != nil {
+		switch err.Error() {
+		case "invalid or expired token":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "too many requests":
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlocked successfully"})
+}
+
+func (h *AuthHandler) UnlockUser(c *gin.Context) {
+	targetUserID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.authService.UnlockUser(c.Request.Context(), targetUserID); err != nil {
+		if err.Error() == "user not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked successfully"})
+}
+
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.verificationService.VerifyEmail(c.Request.Context(), req.Token); err != nil {
+		switch err.Error() {
+		case "invalid verification token", "verification token expired":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified successfully"})
+}
+
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	if err := h.verificationService.ResendVerification(c.Request.Context(), getRequestUser(c).ID); err != nil {
+		switch err.Error() {
+		case "email already verified":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "too many requests":
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Verification email sent"})
+}
+
+// JWKS publishes the public keys that verify access tokens.
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	keys, err := utils.JWKS()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(utils.JWKSMaxAge.Seconds())))
+	c.JSON(http.StatusOK, gin.H{"keys": keys})
+}
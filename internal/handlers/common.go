@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+	"job-portal-api/internal/models"
+	"job-portal-api/pkg/password"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// getRequestUser builds the authenticated user from the claims set by the auth middleware.
+func getRequestUser(c *gin.Context) *models.User {
+	userID, _ := uuid.Parse(c.GetString("user_id"))
+	return &models.User{
+		ID:   userID,
+		Role: models.Role(c.GetString("role")),
+	}
+}
+
+// parseOptionalTime parses an RFC3339 timestamp, returning nil for an empty value.
+func parseOptionalTime(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// respondPasswordPolicyError writes a 400 listing why the password was
+// rejected, if err is a password policy error, and reports whether it did.
+func respondPasswordPolicyError(c *gin.Context, err error) bool {
+	var policyErr *password.PolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": policyErr.Error(), "reasons": policyErr.Violations})
+	return true
+}
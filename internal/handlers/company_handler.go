@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/services"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type CompanyHandler struct {
+	service *services.CompanyService
+}
+
+func NewCompanyHandler(service *services.CompanyService) *CompanyHandler {
+	return &CompanyHandler{service: service}
+}
+
+// respondCompanyError maps company service errors to HTTP responses.
+func respondCompanyError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "company not found", "company member not found", "user not found", "invitation not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "unauthorized to update this company", "unauthorized to delete this company",
+		"unauthorized to view this company's members", "unauthorized to manage this company's members",
+		"unauthorized to create companies", "unauthorized to verify companies", "invitation was sent to a different email address":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case "company slug already exists", "user is already a member of this company", "a company must keep at least one owner":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case "invalid company slug", "invalid company role", "invalid email format", "invalid invitation token", "invitation expired":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func isValidWebsite(website string) bool {
+	u, err := url.ParseRequestURI(website)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func (h *CompanyHandler) CreateCompany(c *gin.Context) {
+	var company models.Company
+	company.Name = c.PostForm("name")
+	company.Slug = c.PostForm("slug")
+	company.Website = c.PostForm("website")
+	company.Description = c.PostForm("description")
+
+	if company.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Company name is required"})
+		return
+	}
+	if company.Website != "" && !isValidWebsite(company.Website) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Website must be an http or https URL"})
+		return
+	}
+
+	var file multipart.File
+
+	fileHeader, err := c.FormFile("logo")
+	if err == nil {
+		f, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
+			return
+		}
+		defer f.Close()
+		file = f
+	}
+
+	createdCompany, err := h.service.CreateCompany(c.Request.Context(), &company, file, getRequestUser(c))
+	if err != nil {
+		respondCompanyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, createdCompany)
+}
+
+func (h *CompanyHandler) GetAllCompanies(c *gin.Context) {
+	companies, err := h.service.GetAllCompanies(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, companies)
+}
+
+func (h *CompanyHandler) GetMyCompanies(c *gin.Context) {
+	companies, err := h.service.GetCompaniesByUser(c.Request.Context(), getRequestUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, companies)
+}
+
+func (h *CompanyHandler) GetCompanyByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	company, err := h.service.GetCompanyByID(c.Request.Context(), id)
+	if err != nil {
+		respondCompanyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, company)
+}
+
+func (h *CompanyHandler) UpdateCompany(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	var company models.Company
+	company.Name = c.PostForm("name")
+	company.Slug = c.PostForm("slug")
+	company.Website = c.PostForm("website")
+	company.Description = c.PostForm("description")
+
+	if company.Website != "" && !isValidWebsite(company.Website) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Website must be an http or https URL"})
+		return
+	}
+
+	var verified *bool
+	if v := c.PostForm("verified"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "verified must be a boolean"})
+			return
+		}
+		verified = &b
+	}
+
+	var file multipart.File
+
+	fileHeader, err := c.FormFile("logo")
+	if err == nil {
+		f, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
+			return
+		}
+		defer f.Close()
+		file = f
+	}
+
+	updatedCompany, err := h.service.UpdateCompany(c.Request.Context(), id, &company, verified, file, getRequestUser(c))
+	if err != nil {
+		respondCompanyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedCompany)
+}
+
+func (h *CompanyHandler) DeleteCompany(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	if err := h.service.DeleteCompany(c.Request.Context(), id, getRequestUser(c)); err != nil {
+		respondCompanyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Company deleted successfully"})
+}
+
+func (h *CompanyHandler) GetMembers(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	members, err := h.service.GetMembers(c.Request.Context(), id, getRequestUser(c))
+	if err != nil {
+		respondCompanyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, members)
+}
+
+func (h *CompanyHandler) AddMember(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	var req struct {
+		UserID uuid.UUID `json:"user_id" binding:"required"`
+		Role   string    `json:"role" binding:"required,oneof=owner recruiter viewer"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.AddMember(c.Request.Context(), id, req.UserID, models.CompanyRole(req.Role), getRequestUser(c)); err != nil {
+		respondCompanyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Member added successfully"})
+}
+
+func (h *CompanyHandler) UpdateMemberRole(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req struct {
+		Role string `json:"role" binding:"required,oneof=owner recruiter viewer"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateMemberRole(c.Request.Context(), id, userID, models.CompanyRole(req.Role), getRequestUser(c)); err != nil {
+		respondCompanyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member role updated successfully"})
+}
+
+func (h *CompanyHandler) RemoveMember(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	if err := h.service.RemoveMember(c.Request.Context(), id, userID, getRequestUser(c)); err != nil {
+		respondCompanyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+func (h *CompanyHandler) CreateInvitation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+		Role  string `json:"role" binding:"required,oneof=owner recruiter viewer"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invitation, err := h.service.CreateInvitation(c.Request.Context(), id, req.Email, models.CompanyRole(req.Role), getRequestUser(c))
+	if err != nil {
+		respondCompanyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"invitation": invitation})
+}
+
+func (h *CompanyHandler) GetPendingInvitations(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	invitations, err := h.service.GetPendingInvitations(c.Request.Context(), id, getRequestUser(c))
+	if err != nil {
+		respondCompanyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, invitations)
+}
+
+func (h *CompanyHandler) RevokeInvitation(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+		return
+	}
+
+	invitationID, err := uuid.Parse(c.Param("invitationId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation ID"})
+		return
+	}
+
+	if err := h.service.RevokeInvitation(c.Request.Context(), id, invitationID, getRequestUser(c)); err != nil {
+		respondCompanyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation revoked successfully"})
+}
+
+func (h *CompanyHandler) AcceptInvitation(c *gin.Context) {
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	invitation, err := h.service.AcceptInvitation(c.Request.Context(), req.Token, getRequestUser(c))
+	if err != nil {
+		respondCompanyError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation accepted successfully", "invitation": invitation})
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http"
+
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type ImpersonationHandler struct {
+	service *services.ImpersonationService
+}
+
+func NewImpersonationHandler(service *services.ImpersonationService) *ImpersonationHandler {
+	return &ImpersonationHandler{service: service}
+}
+
+// Impersonate returns a short-lived access token that acts as the user. The
+// impersonation ends early when its session is revoked through the user's
+// session list.
+func (h *ImpersonationHandler) Impersonate(c *gin.Context) {
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	actorSessionID, err := uuid.Parse(c.GetString("session_id"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Impersonation requires a login session"})
+		return
+	}
+
+	client := &models.Session{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	impersonation, err := h.service.Start(c.Request.Context(), targetID, getRequestUser(c), actorSessionID, client)
+	if err != nil {
+		switch err.Error() {
+		case "cannot impersonate yourself":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case "cannot impersonate this user":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"impersonating":   true,
+		"token":           impersonation.Token,
+		"session_id":      impersonation.SessionID,
+		"expires_at":      impersonation.ExpiresAt,
+		"user":            impersonation.User,
+		"impersonated_by": impersonation.ImpersonatorID,
+	})
+}
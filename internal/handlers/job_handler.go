@@ -1,13 +1,19 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"job-portal-api/internal/models"
 	"job-portal-api/internal/services"
 	"mime/multipart"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"golang.org/x/text/currency"
 )
 
 type JobHandler struct {
@@ -18,6 +24,46 @@ func NewJobHandler(service *services.JobService) *JobHandler {
 	return &JobHandler{service: service}
 }
 
+// parseSalaryForm reads the optional structured salary fields of a job form.
+func parseSalaryForm(c *gin.Context, job *models.Job) error {
+	parseAmount := func(field string) (*int64, error) {
+		v := strings.TrimSpace(c.PostForm(field))
+		if v == "" {
+			return nil, nil
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid salary: %s must be a non-negative whole number", field)
+		}
+		return &n, nil
+	}
+
+	var err error
+	if job.SalaryMin, err = parseAmount("salary_min"); err != nil {
+		return err
+	}
+	if job.SalaryMax, err = parseAmount("salary_max"); err != nil {
+		return err
+	}
+
+	if v := strings.TrimSpace(c.PostForm("salary_currency")); v != "" {
+		unit, err := currency.ParseISO(v)
+		if err != nil {
+			return errors.New("invalid salary: salary_currency must be an ISO 4217 currency code")
+		}
+		job.SalaryCurrency = unit.String()
+	}
+
+	if v := strings.TrimSpace(c.PostForm("salary_period")); v != "" {
+		if v != models.SalaryPeriodHour && v != models.SalaryPeriodMonth && v != models.SalaryPeriodYear {
+			return errors.New("invalid salary: salary_period must be one of: hour, month, year")
+		}
+		job.SalaryPeriod = v
+	}
+
+	return nil
+}
+
 func (h *JobHandler) CreateJob(c *gin.Context) {
 	userIdStr := c.GetString("user_id")
 	userID, err := uuid.Parse(userIdStr)
@@ -35,9 +81,43 @@ func (h *JobHandler) CreateJob(c *gin.Context) {
 	job.JobType = c.PostForm("job_type")
 	job.Company = c.PostForm("company")
 	job.Skills = c.PostFormArray("skills")
+
+	if v := c.PostForm("company_id"); v != "" {
+		companyID, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+			return
+		}
+		job.CompanyID = &companyID
+	}
+	job.Status = models.JobStatus(c.PostForm("status"))
 	job.UserID = userID
 
-	if job.Title == "" || job.Description == "" || job.Location == "" || job.Salary == "" || job.ExperienceLevel == "" || job.Company == "" {
+	if err := parseSalaryForm(c, &job); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if job.ExpiresAt, err = parseOptionalTime(c.PostForm("expires_at")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be an RFC3339 timestamp"})
+		return
+	}
+
+	if job.PublishAt, err = parseOptionalTime(c.PostForm("publish_at")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "publish_at must be an RFC3339 timestamp"})
+		return
+	}
+
+	if job.HasStructuredSalary() {
+		if err := job.ValidateSalary(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		job.Salary = job.FormatSalary()
+	}
+
+	// The company name comes from the company profile when one is referenced
+	if job.Title == "" || job.Description == "" || job.Location == "" || job.Salary == "" || job.ExperienceLevel == "" || (job.Company == "" && job.CompanyID == nil) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "All required fields must be provided"})
 		return
 	}
@@ -57,24 +137,203 @@ func (h *JobHandler) CreateJob(c *gin.Context) {
 		filename = fileHeader.Filename
 	}
 
-	createdJob, err := h.service.CreateJob(c.Request.Context(), &job, file, filename)
+	createdJob, err := h.service.CreateJob(c.Request.Context(), &job, file, filename, getRequestUser(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		switch err.Error() {
+		case "unauthorized to post jobs", "unauthorized to post jobs for this company":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case "company not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "a new job must be either draft or published", "expires_at must be in the future",
+			"publish_at must be in the future", "only draft jobs can be scheduled for publishing":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
 	c.JSON(http.StatusCreated, createdJob)
 }
 
+const (
+	defaultJobsLimit       = 20
+	maxJobsLimit           = 100
+	defaultSuggestionLimit = 10
+	maxSuggestionLimit     = 50
+)
+
+// parsePagination reads the limit and offset query parameters.
+func parsePagination(c *gin.Context) (int, int, error) {
+	limit, offset := defaultJobsLimit, 0
+
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxJobsLimit {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxJobsLimit)
+		}
+		limit = n
+	}
+
+	if v := c.Query("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, 0, errors.New("offset must be a non-negative integer")
+		}
+		offset = n
+	}
+
+	return limit, offset, nil
+}
+
 func (h *JobHandler) GetAllJobs(c *gin.Context) {
-	jobs, err := h.service.GetAllJobs(c.Request.Context())
+	filter := models.JobFilter{
+		Location:        c.Query("location"),
+		JobType:         c.Query("job_type"),
+		ExperienceLevel: c.Query("experience_level"),
+		Company:         c.Query("company"),
+		SkillsMatch:     c.DefaultQuery("skills_match", "any"),
+		Sort:            c.DefaultQuery("sort", "newest"),
+		Cursor:          c.Query("cursor"),
+		Viewer:          getRequestUser(c),
+	}
+
+	// Skills may be repeated (?skills=go&skills=sql) or comma separated
+	for _, v := range c.QueryArray("skills") {
+		for _, skill := range strings.Split(v, ",") {
+			if skill = strings.TrimSpace(skill); skill != "" {
+				filter.Skills = append(filter.Skills, skill)
+			}
+		}
+	}
+
+	if filter.SkillsMatch != "any" && filter.SkillsMatch != "all" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "skills_match must be one of: any, all"})
+		return
+	}
+	if filter.Sort != "newest" && filter.Sort != "oldest" && filter.Sort != "title" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be one of: newest, oldest, title"})
+		return
+	}
+
+	if v := c.Query("created_after"); v != "" {
+		createdAfter, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			createdAfter, err = time.Parse(time.DateOnly, v)
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "created_after must be an RFC3339 timestamp or YYYY-MM-DD date"})
+			return
+		}
+		filter.CreatedAfter = &createdAfter
+	}
+
+	for param, dest := range map[string]**int64{"salary_min": &filter.SalaryMin, "salary_max": &filter.SalaryMax} {
+		if v := c.Query(param); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || n < 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": param + " must be a non-negative whole number"})
+				return
+			}
+			*dest = &n
+		}
+	}
+
+	if v := c.Query("salary_currency"); v != "" {
+		unit, err := currency.ParseISO(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "salary_currency must be an ISO 4217 currency code"})
+			return
+		}
+		filter.SalaryCurrency = unit.String()
+	}
+
+	if v := c.Query("salary_period"); v != "" {
+		if v != models.SalaryPeriodHour && v != models.SalaryPeriodMonth && v != models.SalaryPeriodYear {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "salary_period must be one of: hour, month, year"})
+			return
+		}
+		filter.SalaryPeriod = v
+	}
+
+	// Amounts are only comparable within one currency and pay period
+	if (filter.SalaryMin != nil || filter.SalaryMax != nil) && (filter.SalaryCurrency == "" || filter.SalaryPeriod == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "salary_currency and salary_period are required with salary_min or salary_max"})
+		return
+	}
+
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Limit = limit
+	filter.Offset = offset
+
+	jobs, err := h.service.GetAllJobs(c.Request.Context(), filter)
 	if err != nil {
+		if err.Error() == "invalid cursor" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, jobs)
 }
 
+func (h *JobHandler) SearchJobs(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+
+	limit, offset, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	results, err := h.service.SearchJobs(c.Request.Context(), q, limit, offset, getRequestUser(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+func (h *JobHandler) SuggestJobValues(c *gin.Context) {
+	field := c.Query("field")
+	if field != "title" && field != "company" && field != "location" && field != "skill" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "field must be one of: title, company, location, skill"})
+		return
+	}
+
+	prefix := strings.TrimSpace(c.Query("prefix"))
+	if prefix == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "prefix is required"})
+		return
+	}
+
+	limit := defaultSuggestionLimit
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSuggestionLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxSuggestionLimit)})
+			return
+		}
+		limit = n
+	}
+
+	suggestions, err := h.service.SuggestJobValues(c.Request.Context(), field, prefix, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, suggestions)
+}
+
 func (h *JobHandler) GetJobsByUser(c *gin.Context) {
 	userIdStr := c.GetString("user_id")
 	userID, err := uuid.Parse(userIdStr)
@@ -99,8 +358,12 @@ func (h *JobHandler) GetJobByID(c *gin.Context) {
 		return
 	}
 
-	job, err := h.service.GetJobByID(c.Request.Context(), id)
+	job, err := h.service.GetJobByID(c.Request.Context(), id, getRequestUser(c))
 	if err != nil {
+		if err.Error() == "job not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -115,14 +378,7 @@ func (h *JobHandler) UpdateJob(c *gin.Context) {
 		return
 	}
 
-	userIdStr := c.GetString("user_id")
-	userID, _ := uuid.Parse(userIdStr)
-	isAdmin := c.GetBool("is_admin")
-
-	requestUser := &models.User{
-		ID:      userID,
-		IsAdmin: isAdmin,
-	}
+	requestUser := getRequestUser(c)
 
 	var job models.Job
 	job.Title = c.PostForm("title")
@@ -134,6 +390,30 @@ func (h *JobHandler) UpdateJob(c *gin.Context) {
 	job.Company = c.PostForm("company")
 	job.Skills = c.PostFormArray("skills")
 
+	if v := c.PostForm("company_id"); v != "" {
+		companyID, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid company ID"})
+			return
+		}
+		job.CompanyID = &companyID
+	}
+
+	if err := parseSalaryForm(c, &job); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if job.ExpiresAt, err = parseOptionalTime(c.PostForm("expires_at")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be an RFC3339 timestamp"})
+		return
+	}
+
+	if job.PublishAt, err = parseOptionalTime(c.PostForm("publish_at")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "publish_at must be an RFC3339 timestamp"})
+		return
+	}
+
 	var file multipart.File
 	var filename string
 
@@ -151,10 +431,23 @@ func (h *JobHandler) UpdateJob(c *gin.Context) {
 
 	updatedJob, err := h.service.UpdateJob(c.Request.Context(), id, &job, file, filename, requestUser)
 	if err != nil {
-		if err.Error() == "unauthorized to update this job" {
+		if err.Error() == "unauthorized to update this job" || err.Error() == "unauthorized to post jobs for this company" {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
 		}
+		if err.Error() == "company name and logo are managed on the company profile" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.HasPrefix(err.Error(), "invalid salary") || err.Error() == "expires_at must be in the future" ||
+			err.Error() == "publish_at must be in the future" || err.Error() == "only draft jobs can be scheduled for publishing" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err.Error() == "job not found" || err.Error() == "company not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -170,14 +463,7 @@ func (h *JobHandler) DeleteJob(c *gin.Context) {
 		return
 	}
 
-	userIdStr := c.GetString("user_id")
-	userID, _ := uuid.Parse(userIdStr)
-	isAdmin := c.GetBool("is_admin")
-
-	requestUser := &models.User{
-		ID:      userID,
-		IsAdmin: isAdmin,
-	}
+	requestUser := getRequestUser(c)
 
 	if err := h.service.DeleteJob(c.Request.Context(), id, requestUser); err != nil {
 		if err.Error() == "unauthorized to delete this job" {
@@ -190,3 +476,59 @@ func (h *JobHandler) DeleteJob(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Job deleted successfully"})
 }
+
+func (h *JobHandler) PublishJob(c *gin.Context) {
+	var req struct {
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	// The body is optional; an empty request publishes with the current expiry
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	h.changeJobStatus(c, func(id uuid.UUID, requestUser *models.User) (*models.Job, error) {
+		return h.service.PublishJob(c.Request.Context(), id, req.ExpiresAt, requestUser)
+	})
+}
+
+func (h *JobHandler) PauseJob(c *gin.Context) {
+	h.changeJobStatus(c, func(id uuid.UUID, requestUser *models.User) (*models.Job, error) {
+		return h.service.PauseJob(c.Request.Context(), id, requestUser)
+	})
+}
+
+func (h *JobHandler) CloseJob(c *gin.Context) {
+	h.changeJobStatus(c, func(id uuid.UUID, requestUser *models.User) (*models.Job, error) {
+		return h.service.CloseJob(c.Request.Context(), id, requestUser)
+	})
+}
+
+func (h *JobHandler) changeJobStatus(c *gin.Context, change func(uuid.UUID, *models.User) (*models.Job, error)) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid job ID"})
+		return
+	}
+
+	job, err := change(id, getRequestUser(c))
+	if err != nil {
+		switch {
+		case err.Error() == "job not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case err.Error() == "unauthorized to update this job":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "invalid status transition"):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case err.Error() == "expires_at must be in the future":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+
+	"job-portal-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	service *services.MFAService
+}
+
+func NewMFAHandler(service *services.MFAService) *MFAHandler {
+	return &MFAHandler{service: service}
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required,max=20"`
+}
+
+func (h *MFAHandler) GetStatus(c *gin.Context) {
+	status, err := h.service.GetStatus(c.Request.Context(), getRequestUser(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch MFA status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+func (h *MFAHandler) StartEnrollment(c *gin.Context) {
+	enrollment, err := h.service.StartEnrollment(c.Request.Context(), getRequestUser(c).ID)
+	if err != nil {
+		switch err.Error() {
+		case "mfa already enabled":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *MFAHandler) ConfirmEnrollment(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.service.ConfirmEnrollment(c.Request.Context(), getRequestUser(c).ID, req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication enabled", "recovery_codes": codes})
+}
+
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), getRequestUser(c).ID, req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func (h *MFAHandler) Disable(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.Disable(c.Request.Context(), getRequestUser(c), req.Code); err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+func respondMFAError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "invalid code":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case "mfa not enabled", "mfa enrollment not started":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "mfa already enabled":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case "mfa is required for your role":
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case "too many requests":
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
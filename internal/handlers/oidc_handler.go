@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// oidcStateCookie binds an authorization request to the browser that started
+// it, so that a callback can't be replayed in another browser.
+const oidcStateCookie = "oidc_state"
+
+type OIDCHandler struct {
+	service *services.OIDCService
+}
+
+func NewOIDCHandler(service *services.OIDCService) *OIDCHandler {
+	return &OIDCHandler{service: service}
+}
+
+func (h *OIDCHandler) GetProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": h.service.Providers()})
+}
+
+// Authorize redirects the browser to the provider's login page.
+func (h *OIDCHandler) Authorize(c *gin.Context) {
+	authURL, state, err := h.service.StartLogin(c.Request.Context(), c.Param("provider"), nil)
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+
+	h.setStateCookie(c, state)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Link starts linking a provider to the authenticated user. It returns the
+// URL to open rather than redirecting, since the request carries the user's
+// access token.
+func (h *OIDCHandler) Link(c *gin.Context) {
+	userID := getRequestUser(c).ID
+	authURL, state, err := h.service.StartLogin(c.Request.Context(), c.Param("provider"), &userID)
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+
+	h.setStateCookie(c, state)
+	c.JSON(http.StatusOK, gin.H{"authorization_url": authURL})
+}
+
+// Callback is where the provider sends the browser back with a code.
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	if errCode := c.Query("error"); errCode != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Login with provider failed: " + errCode})
+		return
+	}
+
+	state, code := c.Query("state"), c.Query("code")
+	cookie, _ := c.Cookie(oidcStateCookie)
+	if state == "" || code == "" || subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid state"})
+		return
+	}
+	c.SetCookie(oidcStateCookie, "", -1, "/api/auth/oidc", "", c.Request.TLS != nil, true)
+
+	client := &models.Session{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	result, identity, err := h.service.Callback(c.Request.Context(), c.Param("provider"), state, code, client)
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+
+	if identity != nil {
+		c.JSON(http.StatusCreated, gin.H{"message": "Provider linked successfully", "identity": identity})
+		return
+	}
+
+	if result.Challenge != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required":        true,
+			"mfa_token":           result.Challenge.Token,
+			"expires_at":          result.Challenge.ExpiresAt,
+			"enrollment_required": result.Challenge.EnrollmentRequired,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": result.Tokens.AccessToken, "refresh_token": result.Tokens.RefreshToken, "user": result.User})
+}
+
+func (h *OIDCHandler) GetIdentities(c *gin.Context) {
+	identities, err := h.service.GetIdentities(c.Request.Context(), getRequestUser(c).ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch identities"})
+		return
+	}
+
+	c.JSON(http.StatusOK, identities)
+}
+
+func (h *OIDCHandler) UnlinkIdentity(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid identity ID"})
+		return
+	}
+
+	if err := h.service.UnlinkIdentity(c.Request.Context(), id, getRequestUser(c).ID); err != nil {
+		if err.Error() == "identity not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Provider unlinked successfully"})
+}
+
+func (h *OIDCHandler) setStateCookie(c *gin.Context, state string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, int(services.OIDCLoginTTL.Seconds()), "/api/auth/oidc", "", c.Request.TLS != nil, true)
+}
+
+func respondOIDCError(c *gin.Context, err error) {
+	switch err.Error() {
+	case "provider not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case "invalid state":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case "login with provider failed", "provider did not return a verified email":
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case "email already registered":
+		c.JSON(http.StatusConflict, gin.H{"error": "An account with this email already exists; log in and link the provider instead"})
+	case "identity already linked":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case "provider unavailable":
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
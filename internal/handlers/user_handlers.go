@@ -47,30 +47,10 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	authUserIDStr, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-
-	authUserID, err := uuid.Parse(authUserIDStr.(string))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid auth user ID"})
-		return
-	}
-
-	isAdmin, _ := c.Get("is_admin")
-	isAdminBool, _ := isAdmin.(bool)
-
-	if !isAdminBool && authUserID != targetUserID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You can only update your own profile"})
-		return
-	}
-
 	var req struct {
 		Username       *string            `json:"username"`
 		Email          *string            `json:"email"`
-		IsAdmin        *bool              `json:"is_admin"`
+		Role           *models.Role       `json:"role"`
 		ProfilePicture *models.FileUpload `json:"profile_picture"`
 	}
 
@@ -79,43 +59,29 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.GetUserById(c.Request.Context(), targetUserID)
-	if err != nil {
-		if err.Error() == "user not found" {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
-		return
-	}
-
+	var updateData models.User
 	if req.Username != nil {
-		user.Username = *req.Username
+		updateData.Username = *req.Username
 	}
 	if req.Email != nil {
-		user.Email = *req.Email
+		updateData.Email = *req.Email
 	}
 	if req.ProfilePicture != nil {
-		// If existing picture exists, maybe we should delete it?
-		// The prompt didn't strictly say "delete on update" for User, but it's consistent.
-		// However, I can't easily access the service delete here without expanding the scope.
-		// I'll just update the struct.
-		user.ProfilePicture = *req.ProfilePicture
-	}
-
-	if req.IsAdmin != nil {
-		if !isAdminBool && *req.IsAdmin {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You don't have permission to perform this update"})
-			return
-		}
-		if isAdminBool {
-			user.IsAdmin = *req.IsAdmin
-		}
+		updateData.ProfilePicture = *req.ProfilePicture
 	}
 
-	err = h.userService.UpdateUser(c.Request.Context(), user)
+	user, err := h.userService.UpdateUser(c.Request.Context(), targetUserID, &updateData, req.Role, getRequestUser(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		switch err.Error() {
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case "unauthorized to update this user", "unauthorized to change user roles":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case "invalid role", "invalid email format":
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
+		}
 		return
 	}
 
@@ -130,26 +96,6 @@ func (h *UserHandler) UploadProfilePicture(c *gin.Context) {
 		return
 	}
 
-	authUserIDStr, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-
-	authUserID, err := uuid.Parse(authUserIDStr.(string))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid auth user ID"})
-		return
-	}
-
-	isAdmin, _ := c.Get("is_admin")
-	isAdminBool, _ := isAdmin.(bool)
-
-	if !isAdminBool && authUserID != targetUserID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You can only update your own profile picture"})
-		return
-	}
-
 	file, _, err := c.Request.FormFile("profile_picture")
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get file from request"})
@@ -157,9 +103,16 @@ func (h *UserHandler) UploadProfilePicture(c *gin.Context) {
 	}
 	defer file.Close()
 
-	url, err := h.userService.UploadProfilePicture(c.Request.Context(), targetUserID, file)
+	url, err := h.userService.UploadProfilePicture(c.Request.Context(), targetUserID, file, getRequestUser(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image: " + err.Error()})
+		switch err.Error() {
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case "unauthorized to update this user":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload image: " + err.Error()})
+		}
 		return
 	}
 
@@ -167,12 +120,6 @@ func (h *UserHandler) UploadProfilePicture(c *gin.Context) {
 }
 
 func (h *UserHandler) GetAllUsers(c *gin.Context) {
-	isAdmin, _ := c.Get("is_admin")
-	if isAdminBool, ok := isAdmin.(bool); !ok || !isAdminBool {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can access this resource"})
-		return
-	}
-
 	users, err := h.userService.GetAllUsers(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
@@ -189,24 +136,15 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	authUserIDStr, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		return
-	}
-	authUserID, _ := uuid.Parse(authUserIDStr.(string))
-	isAdmin, _ := c.Get("is_admin")
-	isAdminBool, _ := isAdmin.(bool)
-
-	if !isAdminBool {
-		if authUserID != id {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own account"})
-			return
+	if err := h.userService.DeleteUser(c.Request.Context(), id, getRequestUser(c)); err != nil {
+		switch err.Error() {
+		case "user not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case "unauthorized to delete this user":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
 		}
-	}
-
-	if err := h.userService.DeleteUser(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
 		return
 	}
 
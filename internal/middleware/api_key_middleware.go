@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"job-portal-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyValidator resolves an API key to the key and the current role and
+// email verification of the user it acts as.
+type APIKeyValidator interface {
+	ValidateAPIKey(ctx context.Context, rawKey string) (*models.APIKey, *models.User, error)
+}
+
+var apiKeyValidator APIKeyValidator
+
+// SetAPIKeyValidator makes AuthMiddleware accept API keys in the X-API-Key
+// header or as "Authorization: ApiKey <key>".
+func SetAPIKeyValidator(v APIKeyValidator) {
+	apiKeyValidator = v
+}
+
+// apiKeyRoute gives the scope an API key needs for matching requests. An
+// empty scope means API keys can't be used.
+type apiKeyRoute struct {
+	method string // "*" matches any method
+	prefix string
+	scope  models.APIKeyScope
+}
+
+// apiKeyRoutes is checked in order against the matched route pattern. Routes
+// that match no entry, such as everything under /api/auth, can only be
+// called with an access token.
+var apiKeyRoutes = []apiKeyRoute{
+	{http.MethodDelete, "/api/users/", ""}, // accounts can't be deleted with a key
+	{http.MethodGet, "/api/users/", models.ScopeUsersRead},
+	{"*", "/api/users/", models.ScopeUsersWrite},
+	{http.MethodGet, "/api/jobs/:id/applications", models.ScopeApplicationsRead},
+	{"*", "/api/jobs/:id/applications", models.ScopeApplicationsWrite},
+	{http.MethodGet, "/api/jobs/", models.ScopeJobsRead},
+	{"*", "/api/jobs/", models.ScopeJobsWrite},
+	{http.MethodGet, "/api/applications/", models.ScopeApplicationsRead},
+	{"*", "/api/applications/", models.ScopeApplicationsWrite},
+	// Membership and deleting the company stay with people
+	{"*", "/api/companies/:id/invitations", ""},
+	{http.MethodGet, "/api/companies/:id/members", models.ScopeCompaniesRead},
+	{"*", "/api/companies/:id/members", ""},
+	{http.MethodDelete, "/api/companies/", ""},
+	{http.MethodGet, "/api/companies/", models.ScopeCompaniesRead},
+	{"*", "/api/companies/", models.ScopeCompaniesWrite},
+}
+
+func requiredScope(method, route string) models.APIKeyScope {
+	for _, r := range apiKeyRoutes {
+		if (r.method == "*" || r.method == method) && strings.HasPrefix(route, r.prefix) {
+			return r.scope
+		}
+	}
+	return ""
+}
+
+// companyKeyAllowed reports whether a company API key may call the route.
+// Routes about the key's creator, rather than a company, are off limits; on
+// the others the services keep the key to its company's jobs and
+// applications.
+func companyKeyAllowed(method, route string) bool {
+	switch {
+	case strings.HasPrefix(route, "/api/users/"),
+		route == "/api/jobs/me",
+		route == "/api/applications/me",
+		method == http.MethodPost && route == "/api/jobs/:id/applications":
+		return false
+	}
+	return true
+}
+
+// apiKeyFromRequest returns the API key the request carries, if any.
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	if key, ok := strings.CutPrefix(c.GetHeader("Authorization"), "ApiKey "); ok {
+		return strings.TrimSpace(key)
+	}
+	return ""
+}
+
+// authenticateAPIKey authenticates the request as the user of the API key,
+// provided the key has the scope the route needs. Company keys can only act
+// on their own company's routes.
+func authenticateAPIKey(c *gin.Context, rawKey string) {
+	if apiKeyValidator == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "API keys are not accepted"})
+		return
+	}
+
+	key, user, err := apiKeyValidator.ValidateAPIKey(c.Request.Context(), rawKey)
+	if err != nil {
+		if err.Error() == "invalid api key" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate API key"})
+		return
+	}
+
+	scope := requiredScope(c.Request.Method, c.FullPath())
+	if scope == "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This endpoint can't be used with an API key"})
+		return
+	}
+	if !key.HasScope(scope) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key scope " + string(scope) + " required"})
+		return
+	}
+
+	if key.CompanyID != nil {
+		route := c.FullPath()
+		switch {
+		case route == "/api/companies/" && c.Request.Method != http.MethodGet:
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Company API keys can't create companies"})
+			return
+		case strings.HasPrefix(route, "/api/companies/:id") && c.Param("id") != key.CompanyID.String():
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key belongs to another company"})
+			return
+		case !companyKeyAllowed(c.Request.Method, route):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Company API keys can't act on behalf of their creator"})
+			return
+		}
+	}
+
+	c.Set("user_id", user.ID.String())
+	c.Set("role", string(user.Role))
+	c.Set("email_verified", user.EmailVerifiedAt != nil)
+	c.Set("api_key_id", key.ID.String())
+	setRequestUser(c, user.ID, nil, key)
+
+	c.Next()
+}
@@ -1,16 +1,40 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/policy"
 	"job-portal-api/pkg/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
+// SessionValidator reports whether the session behind an access token is still
+// active and returns the user's current role and email verification.
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, sessionID, userID uuid.UUID) (*models.User, bool, error)
+}
+
+var sessionValidator SessionValidator
+
+// SetSessionValidator makes AuthMiddleware check every token against its
+// session, so that revoked sessions and role changes take effect before the
+// token expires.
+func SetSessionValidator(v SessionValidator) {
+	sessionValidator = v
+}
+
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if rawKey := apiKeyFromRequest(c); rawKey != "" {
+			authenticateAPIKey(c, rawKey)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
@@ -35,32 +59,96 @@ func AuthMiddleware() gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID in token"})
 			return
 		}
+		userID, err := uuid.Parse(userIdStr)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID in token"})
+			return
+		}
+
+		sidStr, _ := claims["sid"].(string)
+		sessionID, err := uuid.Parse(sidStr)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			return
+		}
+
+		// Impersonation tokens also depend on the admin behind them
+		actor, err := actorFromClaims(claims)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			return
+		}
+		if actor != nil {
+			ok, err := checkActor(c.Request.Context(), actor)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate session"})
+				return
+			}
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Impersonation has ended"})
+				return
+			}
+		}
+
+		role, _ := claims["role"].(string)
+		emailVerified := false
+		if sessionValidator != nil {
+			current, active, err := sessionValidator.ValidateSession(c.Request.Context(), sessionID, userID)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate session"})
+				return
+			}
+			if !active {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked"})
+				return
+			}
+			role = string(current.Role)
+			emailVerified = current.EmailVerifiedAt != nil
+		}
 
 		c.Set("user_id", userIdStr)
+		c.Set("session_id", sidStr)
+		c.Set("role", role)
+		c.Set("email_verified", emailVerified)
 
 		if username, ok := claims["username"].(string); ok {
 			c.Set("username", username)
 		}
 
-		if isAdmin, ok := claims["is_admin"].(bool); ok {
-			c.Set("is_admin", isAdmin)
+		var impersonatorID *uuid.UUID
+		// Make impersonation visible to the client and audit the request
+		if actor != nil {
+			impersonatorID = &actor.id
+			c.Set("impersonator_id", actor.id.String())
+			c.Header("X-Impersonated-By", actor.id.String())
+			defer auditImpersonatedRequest(c, actor, userID, sessionID)
 		}
+		setRequestUser(c, userID, impersonatorID, nil)
 
 		c.Next()
 	}
 
 }
 
-func AdminMiddleware() gin.HandlerFunc {
+// RequirePermission aborts the request unless the authenticated user's role
+// grants perm. It must run after AuthMiddleware.
+func RequirePermission(perm policy.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		isAdmin, exists := c.Get("is_admin")
-		if !exists {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		if !policy.RoleCan(models.Role(c.GetString("role")), perm) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Permission " + string(perm) + " required"})
 			return
 		}
 
-		if isAdminBool, ok := isAdmin.(bool); !ok || !isAdminBool {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+		c.Next()
+	}
+}
+
+// RequireVerifiedEmail aborts the request unless the authenticated user has
+// verified their email address. It must run after AuthMiddleware.
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool("email_verified") {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Email address must be verified"})
 			return
 		}
 
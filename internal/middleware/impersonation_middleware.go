@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/policy"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ImpersonationAuditor records the requests made while impersonating a user.
+type ImpersonationAuditor interface {
+	RecordRequest(ctx context.Context, req *models.ImpersonatedRequest) error
+}
+
+var impersonationAuditor ImpersonationAuditor
+
+// SetImpersonationAuditor makes AuthMiddleware accept impersonation tokens,
+// recording every request made with them.
+func SetImpersonationAuditor(a ImpersonationAuditor) {
+	impersonationAuditor = a
+}
+
+// actor is the admin behind an impersonation token, from its act claim.
+type actor struct {
+	id        uuid.UUID
+	sessionID uuid.UUID
+}
+
+// actorFromClaims returns the actor of an impersonation token, nil for an
+// ordinary access token, or an error if the act claim is malformed.
+func actorFromClaims(claims map[string]interface{}) (*actor, error) {
+	raw, ok := claims["act"]
+	if !ok {
+		return nil, nil
+	}
+	act, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errInvalidActor
+	}
+	sub, _ := act["sub"].(string)
+	sid, _ := act["sid"].(string)
+	id, err := uuid.Parse(sub)
+	if err != nil {
+		return nil, errInvalidActor
+	}
+	sessionID, err := uuid.Parse(sid)
+	if err != nil {
+		return nil, errInvalidActor
+	}
+	return &actor{id: id, sessionID: sessionID}, nil
+}
+
+var errInvalidActor = errors.New("invalid act claim")
+
+// checkActor reports whether the actor's own session is still active and
+// their role still allows impersonation.
+func checkActor(ctx context.Context, a *actor) (bool, error) {
+	if sessionValidator == nil || impersonationAuditor == nil {
+		return false, nil
+	}
+	current, active, err := sessionValidator.ValidateSession(ctx, a.sessionID, a.id)
+	if err != nil || !active {
+		return false, err
+	}
+	return policy.Can(current, policy.UsersImpersonate), nil
+}
+
+// auditImpersonatedRequest records the request once it has been handled.
+func auditImpersonatedRequest(c *gin.Context, a *actor, userID, sessionID uuid.UUID) {
+	req := &models.ImpersonatedRequest{
+		ImpersonatorID: a.id,
+		UserID:         userID,
+		SessionID:      sessionID,
+		Method:         c.Request.Method,
+		Path:           c.Request.URL.Path,
+		Status:         c.Writer.Status(),
+		IP:             c.ClientIP(),
+		RemoteIP:       c.RemoteIP(),
+		UserAgent:      c.Request.UserAgent(),
+	}
+	// The client may be gone by now; the record must still be written
+	ctx := context.WithoutCancel(c.Request.Context())
+	if err := impersonationAuditor.RecordRequest(ctx, req); err != nil {
+		log.Printf("Failed to record %s %s impersonated by user %s: %v", req.Method, req.Path, a.id, err)
+	}
+}
+
+// RejectImpersonation aborts requests made while impersonating a user, for
+// actions only the user themselves may take. It must run after
+// AuthMiddleware.
+func RejectImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("impersonator_id") != "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Not allowed while impersonating a user"})
+			return
+		}
+
+		c.Next()
+	}
+}
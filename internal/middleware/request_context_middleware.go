@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/requestctx"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const maxRequestIDLength = 64
+
+// RequestContext gives every request an ID, echoed in the X-Request-ID
+// header, and stores it with the client's IP and user agent in the request
+// context for the audit log. A request ID sent by a proxy in front of the API
+// is kept if it looks safe to log.
+func RequestContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader("X-Request-ID")
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Set("request_id", requestID)
+		c.Header("X-Request-ID", requestID)
+
+		info := &requestctx.Info{
+			RequestID: requestID,
+			IP:        c.ClientIP(),
+			RemoteIP:  c.RemoteIP(),
+			UserAgent: c.Request.UserAgent(),
+		}
+		c.Request = c.Request.WithContext(requestctx.With(c.Request.Context(), info))
+
+		c.Next()
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// setRequestUser records the authenticated user and the API key they use, if
+// any, in the request info. Audit events name them as the actor, and services
+// use it to keep company keys within their company.
+func setRequestUser(c *gin.Context, userID uuid.UUID, impersonatorID *uuid.UUID, key *models.APIKey) {
+	info := requestctx.From(c.Request.Context())
+	if info == nil {
+		// Company keys depend on the info, so don't go without it
+		info = &requestctx.Info{IP: c.ClientIP(), RemoteIP: c.RemoteIP(), UserAgent: c.Request.UserAgent()}
+		c.Request = c.Request.WithContext(requestctx.With(c.Request.Context(), info))
+	}
+	info.UserID = &userID
+	info.ImpersonatorID = impersonatorID
+	info.APIKeyID = nil
+	info.APIKeyCompanyID = nil
+	if key != nil {
+		info.APIKeyID = &key.ID
+		info.APIKeyCompanyID = key.CompanyID
+	}
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyScope limits which endpoints an API key can call.
+type APIKeyScope string
+
+const (
+	ScopeJobsRead          APIKeyScope = "jobs:read"
+	ScopeJobsWrite         APIKeyScope = "jobs:write"
+	ScopeApplicationsRead  APIKeyScope = "applications:read"
+	ScopeApplicationsWrite APIKeyScope = "applications:write"
+	ScopeCompaniesRead     APIKeyScope = "companies:read"
+	ScopeCompaniesWrite    APIKeyScope = "companies:write"
+	ScopeUsersRead         APIKeyScope = "users:read"
+	ScopeUsersWrite        APIKeyScope = "users:write"
+)
+
+var apiKeyScopes = map[APIKeyScope]bool{
+	ScopeJobsRead:          true,
+	ScopeJobsWrite:         true,
+	ScopeApplicationsRead:  true,
+	ScopeApplicationsWrite: true,
+	ScopeCompaniesRead:     true,
+	ScopeCompaniesWrite:    true,
+	ScopeUsersRead:         true,
+	ScopeUsersWrite:        true,
+}
+
+func (s APIKeyScope) IsValid() bool {
+	return apiKeyScopes[s]
+}
+
+// APIKey authenticates integrations as its user without a password. A
+// company key belongs to a company and only works while the member who
+// created it still recruits for that company.
+type APIKey struct {
+	ID         uuid.UUID     `json:"id"`
+	UserID     uuid.UUID     `json:"user_id"`
+	CompanyID  *uuid.UUID    `json:"company_id"`
+	Name       string        `json:"name"`
+	Prefix     string        `json:"prefix"`
+	Scopes     []APIKeyScope `json:"scopes"`
+	ExpiresAt  *time.Time    `json:"expires_at"`
+	LastUsedAt *time.Time    `json:"last_used_at"`
+	RevokedAt  *time.Time    `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+func (k *APIKey) HasScope(scope APIKeyScope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ApplicationStatus string
+
+const (
+	ApplicationStatusApplied   ApplicationStatus = "applied"
+	ApplicationStatusScreening ApplicationStatus = "screening"
+	ApplicationStatusInterview ApplicationStatus = "interview"
+	ApplicationStatusOffer     ApplicationStatus = "offer"
+	ApplicationStatusHired     ApplicationStatus = "hired"
+	ApplicationStatusRejected  ApplicationStatus = "rejected"
+	ApplicationStatusWithdrawn ApplicationStatus = "withdrawn"
+)
+
+type Application struct {
+	ID          uuid.UUID         `json:"id"`
+	JobID       uuid.UUID         `json:"job_id"`
+	UserID      uuid.UUID         `json:"user_id"`
+	CoverLetter string            `json:"cover_letter"`
+	Resume      FileUpload        `json:"resume"`
+	Status      ApplicationStatus `json:"status"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+type ApplicationStatusHistory struct {
+	ID            uuid.UUID          `json:"id"`
+	ApplicationID uuid.UUID          `json:"application_id"`
+	FromStatus    *ApplicationStatus `json:"from_status"`
+	ToStatus      ApplicationStatus  `json:"to_status"`
+	ActorID       *uuid.UUID         `json:"actor_id"`
+	Note          string             `json:"note"`
+	CreatedAt     time.Time          `json:"created_at"`
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit actions, named "<target>.<verb>"
+const (
+	AuditUserRegister            = "user.register"
+	AuditUserLogin               = "user.login"
+	AuditUserLogout              = "user.logout"
+	AuditUserUpdate              = "user.update"
+	AuditUserDelete              = "user.delete"
+	AuditUserLock                = "user.lock"
+	AuditUserUnlock              = "user.unlock"
+	AuditUserImpersonate         = "user.impersonate"
+	AuditSessionRevoke           = "user.session.revoke"
+	AuditPasswordChange          = "user.password.change"
+	AuditPasswordReset           = "user.password.reset"
+	AuditPasswordSet             = "user.password.set"
+	AuditMFAEnable               = "user.mfa.enable"
+	AuditMFADisable              = "user.mfa.disable"
+	AuditMFARecoveryCodes        = "user.mfa.recovery_codes"
+	AuditIdentityLink            = "identity.link"
+	AuditIdentityUnlink          = "identity.unlink"
+	AuditAPIKeyCreate            = "api_key.create"
+	AuditAPIKeyRevoke            = "api_key.revoke"
+	AuditJobCreate               = "job.create"
+	AuditJobUpdate               = "job.update"
+	AuditJobDelete               = "job.delete"
+	AuditCompanyUpdate           = "company.update"
+	AuditCompanyDelete           = "company.delete"
+	AuditCompanyMemberAdd        = "company.member.add"
+	AuditCompanyMemberUpdate     = "company.member.update"
+	AuditCompanyMemberRemove     = "company.member.remove"
+	AuditCompanyInvitationCreate = "company.invitation.create"
+	AuditCompanyInvitationRevoke = "company.invitation.revoke"
+	AuditCompanyInvitationAccept = "company.invitation.accept"
+)
+
+// Audit target types
+const (
+	AuditTargetUser     = "user"
+	AuditTargetIdentity = "identity"
+	AuditTargetAPIKey   = "api_key"
+	AuditTargetJob      = "job"
+	AuditTargetCompany  = "company"
+)
+
+// AuditChange is the old and new value of a changed field. From is nil for
+// created records and To for deleted ones.
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// AuditEvent records a security or data relevant action. Actor, IPs, user
+// agent and request ID come from the request the action was taken in; they
+// are empty for actions of background jobs. IP is the client as reported by
+// trusted proxies and RemoteIP the address the connection came from.
+type AuditEvent struct {
+	ID             uuid.UUID              `json:"id"`
+	ActorID        *uuid.UUID             `json:"actor_id"`
+	ImpersonatorID *uuid.UUID             `json:"impersonator_id,omitempty"`
+	APIKeyID       *uuid.UUID             `json:"api_key_id,omitempty"`
+	Action         string                 `json:"action"`
+	TargetType     string                 `json:"target_type"`
+	TargetID       *uuid.UUID             `json:"target_id"`
+	Changes        map[string]AuditChange `json:"changes,omitempty"`
+	IP             string                 `json:"ip"`
+	RemoteIP       string                 `json:"remote_ip"`
+	UserAgent      string                 `json:"user_agent"`
+	RequestID      string                 `json:"request_id"`
+	CreatedAt      time.Time              `json:"created_at"`
+}
+
+// AuditFilter narrows down audit event listings. Zero values match
+// everything.
+type AuditFilter struct {
+	ActorID    *uuid.UUID
+	Action     string
+	TargetType string
+	TargetID   *uuid.UUID
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Offset     int
+}
+
+// AuditEventList is the paginated envelope of audit event listings.
+type AuditEventList struct {
+	Items []AuditEvent `json:"items"`
+	Total int          `json:"total"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CompanyRole string
+
+const (
+	CompanyRoleOwner     CompanyRole = "owner"
+	CompanyRoleRecruiter CompanyRole = "recruiter"
+	CompanyRoleViewer    CompanyRole = "viewer"
+)
+
+// companyRoleRank orders roles so that a higher role includes the lower ones.
+var companyRoleRank = map[CompanyRole]int{
+	CompanyRoleViewer:    1,
+	CompanyRoleRecruiter: 2,
+	CompanyRoleOwner:     3,
+}
+
+// IsValid reports whether r is a known company role.
+func (r CompanyRole) IsValid() bool {
+	_, ok := companyRoleRank[r]
+	return ok
+}
+
+// AtLeast reports whether r grants at least the permissions of min.
+func (r CompanyRole) AtLeast(min CompanyRole) bool {
+	return companyRoleRank[r] >= companyRoleRank[min]
+}
+
+type Company struct {
+	ID          uuid.UUID  `json:"id"`
+	Name        string     `json:"name"`
+	Slug        string     `json:"slug"`
+	Website     string     `json:"website"`
+	Description string     `json:"description"`
+	Logo        FileUpload `json:"logo"`
+	Verified    bool       `json:"verified"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type CompanyMember struct {
+	CompanyID uuid.UUID   `json:"company_id"`
+	UserID    uuid.UUID   `json:"user_id"`
+	Username  string      `json:"username"`
+	Email     string      `json:"email"`
+	Role      CompanyRole `json:"role"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type CompanyInvitation struct {
+	ID         uuid.UUID   `json:"id"`
+	CompanyID  uuid.UUID   `json:"company_id"`
+	Email      string      `json:"email"`
+	Role       CompanyRole `json:"role"`
+	InvitedBy  *uuid.UUID  `json:"invited_by"`
+	ExpiresAt  time.Time   `json:"expires_at"`
+	AcceptedAt *time.Time  `json:"accepted_at"`
+	AcceptedBy *uuid.UUID  `json:"accepted_by"`
+	RevokedAt  *time.Time  `json:"revoked_at"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity links an account at an external OpenID provider to a user.
+type UserIdentity struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"subject"`
+	Email       string     `json:"email"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// OIDCLoginState is an authorization request waiting for the provider to
+// redirect back.
+type OIDCLoginState struct {
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+	// UserID is set when a logged-in user links the provider instead of
+	// logging in with it.
+	UserID    *uuid.UUID
+	ExpiresAt time.Time
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Impersonation is a short-lived session in which an admin acts as another
+// user. Its access token can't be refreshed.
+type Impersonation struct {
+	Token          string    `json:"token"`
+	SessionID      uuid.UUID `json:"session_id"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	User           *User     `json:"user"`
+	ImpersonatorID uuid.UUID `json:"impersonated_by"`
+}
+
+// ImpersonatedRequest records one request made while impersonating a user.
+type ImpersonatedRequest struct {
+	ID             uuid.UUID `json:"id"`
+	ImpersonatorID uuid.UUID `json:"impersonated_by"`
+	UserID         uuid.UUID `json:"user_id"`
+	SessionID      uuid.UUID `json:"session_id"`
+	Method         string    `json:"method"`
+	Path           string    `json:"path"`
+	Status         int       `json:"status"`
+	IP             string    `json:"ip"`
+	RemoteIP       string    `json:"remote_ip"`
+	UserAgent      string    `json:"user_agent"`
+	CreatedAt      time.Time `json:"created_at"`
+}
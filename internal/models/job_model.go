@@ -1,6 +1,9 @@
 package models
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,13 +14,142 @@ type Job struct {
 	Title           string     `json:"title"`
 	Description     string     `json:"description"`
 	Location        string     `json:"location"`
-	Salary          string     `json:"salary"`
+	Salary          string     `json:"salary"` // Display string, derived from the structured fields when set
+	SalaryMin       *int64     `json:"salary_min"`
+	SalaryMax       *int64     `json:"salary_max"`
+	SalaryCurrency  string     `json:"salary_currency"`
+	SalaryPeriod    string     `json:"salary_period"`
 	ExperienceLevel string     `json:"experience_level"`
 	Skills          []string   `json:"skills"`
 	JobType         string     `json:"job_type"`
 	Company         string     `json:"company"`
+	CompanyID       *uuid.UUID `json:"company_id"`
 	CompanyLogo     FileUpload `json:"company_logo"`
+	Status          JobStatus  `json:"status"`
+	PublishedAt     *time.Time `json:"published_at"`
+	PublishAt       *time.Time `json:"publish_at"` // Scheduled publication time of a draft
+	ExpiresAt       *time.Time `json:"expires_at"`
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
 	UserID          uuid.UUID  `json:"user_id"`
 }
+
+type JobStatus string
+
+const (
+	JobStatusDraft     JobStatus = "draft"
+	JobStatusPublished JobStatus = "published"
+	JobStatusPaused    JobStatus = "paused"
+	JobStatusClosed    JobStatus = "closed"
+	JobStatusExpired   JobStatus = "expired"
+)
+
+// IsOpen reports whether the job is publicly listed and accepting applications.
+func (j *Job) IsOpen() bool {
+	return j.Status == JobStatusPublished && (j.ExpiresAt == nil || j.ExpiresAt.After(time.Now()))
+}
+
+const (
+	SalaryPeriodHour  = "hour"
+	SalaryPeriodMonth = "month"
+	SalaryPeriodYear  = "year"
+)
+
+// HasStructuredSalary reports whether the job carries a salary range rather
+// than only the legacy free-text salary.
+func (j *Job) HasStructuredSalary() bool {
+	return j.SalaryMin != nil || j.SalaryMax != nil
+}
+
+// ValidateSalary checks that a structured salary is a complete, ordered range.
+func (j *Job) ValidateSalary() error {
+	if !j.HasStructuredSalary() {
+		return nil
+	}
+	if j.SalaryCurrency == "" {
+		return errors.New("invalid salary: salary_currency is required with a salary range")
+	}
+	if j.SalaryPeriod == "" {
+		return errors.New("invalid salary: salary_period is required with a salary range")
+	}
+	if j.SalaryMin != nil && j.SalaryMax != nil && *j.SalaryMin > *j.SalaryMax {
+		return errors.New("invalid salary: salary_min must not exceed salary_max")
+	}
+	return nil
+}
+
+// FormatSalary renders the structured salary as a display string such as
+// "80,000 - 100,000 EUR/year".
+func (j *Job) FormatSalary() string {
+	var amount string
+	switch {
+	case j.SalaryMin != nil && j.SalaryMax != nil && *j.SalaryMin != *j.SalaryMax:
+		amount = formatAmount(*j.SalaryMin) + " - " + formatAmount(*j.SalaryMax)
+	case j.SalaryMin != nil:
+		amount = formatAmount(*j.SalaryMin)
+	case j.SalaryMax != nil:
+		amount = "Up to " + formatAmount(*j.SalaryMax)
+	default:
+		return ""
+	}
+	return fmt.Sprintf("%s %s/%s", amount, j.SalaryCurrency, j.SalaryPeriod)
+}
+
+// formatAmount adds thousands separators to n.
+func formatAmount(n int64) string {
+	s := strconv.FormatInt(n, 10)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// JobFilter holds the listing options accepted by GET /api/jobs.
+type JobFilter struct {
+	Location        string
+	JobType         string
+	ExperienceLevel string
+	Company         string
+	Skills          []string
+	SkillsMatch     string // "any" or "all"
+	CreatedAfter    *time.Time
+	SalaryMin       *int64 // Jobs whose range reaches at least this amount
+	SalaryMax       *int64 // Jobs whose range starts at or below this amount
+	SalaryCurrency  string // Required with SalaryMin or SalaryMax, as is SalaryPeriod
+	SalaryPeriod    string
+	Sort            string // "newest", "oldest" or "title"
+	Limit           int
+	Offset          int
+	Cursor          string
+
+	// Viewer decides which unpublished jobs are included: users allowed to
+	// read any job see every job, everyone else only open jobs plus their own.
+	Viewer *User
+}
+
+// JobList is the paginated envelope returned by job listings.
+type JobList struct {
+	Items      []Job  `json:"items"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// JobSearchResult is a job matched by full-text search.
+type JobSearchResult struct {
+	Job
+	Score float64 `json:"score"`
+	// Snippet is HTML: escaped description text with the matched terms in
+	// <mark> tags.
+	Snippet string `json:"snippet"`
+}
+
+type JobSearchList struct {
+	Items []JobSearchResult `json:"items"`
+	Total int               `json:"total"`
+}
+
+// JobSuggestion is a distinct autocomplete value and the number of jobs using it.
+type JobSuggestion struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserMFA is a user's TOTP enrollment. It only protects logins once
+// EnabledAt is set.
+type UserMFA struct {
+	UserID       uuid.UUID  `json:"user_id"`
+	Secret       string     `json:"-"`
+	EnabledAt    *time.Time `json:"enabled_at"`
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// MFAEnrollment is what an authenticator app needs to add the account.
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	// QRCode is a data URI of a PNG image of the otpauth URI.
+	QRCode string `json:"qr_code"`
+}
+
+// MFAStatus describes a user's second factor.
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at"`
+	Required               bool       `json:"required"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// MFAChallenge is returned by a login that still needs a second factor. The
+// token is exchanged, together with a code, for the real token pair.
+type MFAChallenge struct {
+	Token     string    `json:"mfa_token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// EnrollmentRequired is set when the user's role requires a second
+	// factor that the user hasn't set up yet; they must enroll first.
+	EnrollmentRequired bool `json:"enrollment_required"`
+}
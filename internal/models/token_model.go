@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is a stored refresh token. The token itself is only handed to
+// the client; the database keeps its hash.
+type RefreshToken struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	FamilyID   uuid.UUID  `json:"family_id"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RotatedAt  *time.Time `json:"rotated_at"`
+	ReplacedBy *uuid.UUID `json:"replaced_by"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TokenPair is returned on login and refresh.
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// LoginResult is the outcome of a login: either a token pair or, when a
+// second factor is needed, an MFA challenge.
+type LoginResult struct {
+	Tokens    *TokenPair
+	Challenge *MFAChallenge
+	User      *User
+	// RecoveryCodes is set when the login completed an MFA enrollment.
+	RecoveryCodes []string
+}
+
+// Session is one login of a user. Access tokens reference it through their
+// sid claim and its refresh tokens share its ID as their family.
+type Session struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Device     string     `json:"device"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	Current    bool       `json:"current"`
+	// ImpersonatorID is the admin acting as the user in this session.
+	ImpersonatorID *uuid.UUID `json:"impersonated_by,omitempty"`
+}
@@ -6,15 +6,38 @@ import (
 	"github.com/google/uuid"
 )
 
+// Role is the portal-wide role of a user. What each role may do is defined by
+// the role_permissions table and enforced through the policy package.
+type Role string
+
+const (
+	RoleCandidate Role = "candidate"
+	RoleEmployer  Role = "employer"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleCandidate, RoleEmployer, RoleModerator, RoleAdmin:
+		return true
+	}
+	return false
+}
+
 type User struct {
-	ID                   uuid.UUID  `json:"id"`
-	Username             string     `json:"username"`
-	Password             string     `json:"-"` // Never send password in JSON response
-	Email                string     `json:"email"`
-	CreatedAt            time.Time  `json:"created_at"`
-	UpdatedAt            time.Time  `json:"updated_at"`
-	IsAdmin              bool       `json:"is_admin"`
-	ProfilePicture       FileUpload `json:"profile_picture"` // Default empty object
-	PasswordResetToken   *string    `json:"-"`
-	PasswordResetExpires *time.Time `json:"-"`
+	ID                     uuid.UUID  `json:"id"`
+	Username               string     `json:"username"`
+	Password               string     `json:"-"` // Never send password in JSON response
+	Email                  string     `json:"email"`
+	EmailVerifiedAt        *time.Time `json:"email_verified_at"`
+	CreatedAt              time.Time  `json:"created_at"`
+	UpdatedAt              time.Time  `json:"updated_at"`
+	Role                   Role       `json:"role"`
+	ProfilePicture         FileUpload `json:"profile_picture"` // Default empty object
+	PasswordResetTokenHash *string    `json:"-"`
+	PasswordResetExpires   *time.Time `json:"-"`
+	FailedLoginAttempts    int        `json:"-"`
+	LastFailedLoginAt      *time.Time `json:"-"`
+	LockedUntil            *time.Time `json:"-"`
 }
@@ -0,0 +1,100 @@
+// Package policy decides what a user may do based on their role. Services ask
+// Can before acting on resources they do not own; ownership and company
+// membership checks stay with the services.
+package policy
+
+import (
+	"sync"
+
+	"job-portal-api/internal/models"
+)
+
+// Permission names an action, written as "resource:action" or
+// "resource:action:any" when it applies to resources of other users.
+type Permission string
+
+const (
+	JobsCreate    Permission = "jobs:create"
+	JobsReadAny   Permission = "jobs:read:any"
+	JobsUpdateAny Permission = "jobs:update:any"
+	JobsDeleteAny Permission = "jobs:delete:any"
+
+	ApplicationsCreate    Permission = "applications:create"
+	ApplicationsReadAny   Permission = "applications:read:any"
+	ApplicationsUpdateAny Permission = "applications:update:any"
+
+	CompaniesCreate    Permission = "companies:create"
+	CompaniesManageAny Permission = "companies:manage:any"
+	CompaniesVerify    Permission = "companies:verify"
+
+	UsersReadAny           Permission = "users:read:any"
+	UsersUpdateAny         Permission = "users:update:any"
+	UsersDeleteAny         Permission = "users:delete:any"
+	UsersRoleUpdate        Permission = "users:role:update"
+	UsersPasswordUpdateAny Permission = "users:password:update:any"
+	UsersUnlockAny         Permission = "users:unlock:any"
+	UsersImpersonate       Permission = "users:impersonate"
+
+	AuditRead Permission = "audit:read"
+)
+
+// defaultPermissions mirrors the seed data of the role_permissions table and
+// is used until Load is called with the rows from the database.
+var defaultPermissions = map[models.Role][]Permission{
+	models.RoleCandidate: {ApplicationsCreate},
+	models.RoleEmployer:  {JobsCreate, CompaniesCreate},
+	models.RoleModerator: {
+		JobsReadAny, JobsUpdateAny, JobsDeleteAny,
+		ApplicationsReadAny,
+		CompaniesVerify,
+		UsersReadAny,
+	},
+	models.RoleAdmin: {
+		JobsCreate, JobsReadAny, JobsUpdateAny, JobsDeleteAny,
+		ApplicationsCreate, ApplicationsReadAny, ApplicationsUpdateAny,
+		CompaniesCreate, CompaniesManageAny, CompaniesVerify,
+		UsersReadAny, UsersUpdateAny, UsersDeleteAny, UsersRoleUpdate, UsersPasswordUpdateAny, UsersUnlockAny,
+		UsersImpersonate, AuditRead,
+	},
+}
+
+var (
+	mu     sync.RWMutex
+	grants = build(defaultPermissions)
+)
+
+func build(perms map[models.Role][]Permission) map[models.Role]map[Permission]bool {
+	g := make(map[models.Role]map[Permission]bool, len(perms))
+	for role, list := range perms {
+		g[role] = make(map[Permission]bool, len(list))
+		for _, p := range list {
+			g[role][p] = true
+		}
+	}
+	return g
+}
+
+// Load replaces the permission table, typically with the contents of the
+// role_permissions table at startup.
+func Load(perms map[models.Role][]Permission) {
+	g := build(perms)
+	mu.Lock()
+	grants = g
+	mu.Unlock()
+}
+
+// RoleCan reports whether the role has been granted the permission.
+func RoleCan(role models.Role, perm Permission) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return grants[role][perm]
+}
+
+// Can reports whether the user's role grants the permission. A nil user
+// (anonymous request) has no permissions.
+func Can(user *models.User, perm Permission) bool {
+	if user == nil {
+		return false
+	}
+	return RoleCan(user.Role, perm)
+}
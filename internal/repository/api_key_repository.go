@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"job-portal-api/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type APIKeyRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(pool *pgxpool.Pool) *APIKeyRepository {
+	return &APIKeyRepository{pool: pool}
+}
+
+const apiKeyColumns = `k.id, k.user_id, k.company_id, k.name, k.prefix, k.scopes, k.expires_at, k.last_used_at, k.revoked_at, k.created_at`
+
+func scanAPIKey(row pgx.Row, extra ...interface{}) (models.APIKey, error) {
+	var k models.APIKey
+	var scopes []string
+	dest := append([]interface{}{
+		&k.ID, &k.UserID, &k.CompanyID, &k.Name, &k.Prefix, &scopes, &k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return k, err
+	}
+	for _, s := range scopes {
+		k.Scopes = append(k.Scopes, models.APIKeyScope(s))
+	}
+	return k, nil
+}
+
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey, keyHash string) error {
+	scopes := make([]string, len(key.Scopes))
+	for i, s := range key.Scopes {
+		scopes[i] = string(s)
+	}
+
+	query := `
+		INSERT INTO api_keys (user_id, company_id, name, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`
+	err := r.pool.QueryRow(ctx, query, key.UserID, key.CompanyID, key.Name, key.Prefix, keyHash, scopes, key.ExpiresAt).
+		Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) GetAPIKeyByID(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys k WHERE k.id = $1 AND k.revoked_at IS NULL`
+	key, err := scanAPIKey(r.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("api key not found")
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return &key, nil
+}
+
+// GetUserAPIKeys returns the user's personal keys that haven't been revoked.
+func (r *APIKeyRepository) GetUserAPIKeys(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + ` FROM api_keys k
+		WHERE k.user_id = $1 AND k.company_id IS NULL AND k.revoked_at IS NULL
+		ORDER BY k.created_at DESC
+	`
+	return r.queryAPIKeys(ctx, query, userID)
+}
+
+// GetCompanyAPIKeys returns the company's keys that haven't been revoked.
+func (r *APIKeyRepository) GetCompanyAPIKeys(ctx context.Context, companyID uuid.UUID) ([]models.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + ` FROM api_keys k
+		WHERE k.company_id = $1 AND k.revoked_at IS NULL
+		ORDER BY k.created_at DESC
+	`
+	return r.queryAPIKeys(ctx, query, companyID)
+}
+
+func (r *APIKeyRepository) queryAPIKeys(ctx context.Context, query string, args ...interface{}) ([]models.APIKey, error) {
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// GetActiveAPIKeyByHash returns a usable key together with the role and
+// email verification of the user it acts as. Company keys stop working once
+// their creator is no longer an owner or recruiter of the company.
+func (r *APIKeyRepository) GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, *models.User, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `, u.role, u.email_verified_at FROM api_keys k
+		JOIN users u ON u.id = k.user_id
+		WHERE k.key_hash = $1 AND k.revoked_at IS NULL AND (k.expires_at IS NULL OR k.expires_at > NOW())
+		  AND (k.company_id IS NULL OR EXISTS (
+			SELECT 1 FROM company_members m
+			WHERE m.company_id = k.company_id AND m.user_id = k.user_id AND m.role IN ('owner', 'recruiter')
+		  ))
+	`
+	var user models.User
+	key, err := scanAPIKey(r.pool.QueryRow(ctx, query, keyHash), &user.Role, &user.EmailVerifiedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil, errors.New("invalid api key")
+		}
+		return nil, nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	user.ID = key.UserID
+	return &key, &user, nil
+}
+
+// TouchAPIKey records that the key was used. The timestamp is only written
+// once a minute so that busy integrations don't update the row on every call.
+func (r *APIKeyRepository) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	_, err := r.pool.Exec(ctx,
+		`UPDATE api_keys SET last_used_at = NOW() WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update api key: %w", err)
+	}
+	return nil
+}
+
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	commandTag, err := r.pool.Exec(ctx, `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errors.New("api key not found")
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"job-portal-api/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ApplicationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewApplicationRepository(pool *pgxpool.Pool) *ApplicationRepository {
+	return &ApplicationRepository{pool: pool}
+}
+
+func (r *ApplicationRepository) CreateApplication(ctx context.Context, app *models.Application) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO applications (job_id, user_id, cover_letter, resume)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, status, created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, query, app.JobID, app.UserID, app.CoverLetter, app.Resume).
+		Scan(&app.ID, &app.Status, &app.CreatedAt, &app.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return errors.New("you have already applied to this job")
+		}
+		return fmt.Errorf("failed to create application: %w", err)
+	}
+
+	// Record the initial state so the history always starts at "applied"
+	_, err = tx.Exec(ctx,
+		`INSERT INTO application_status_history (application_id, from_status, to_status, actor_id) VALUES ($1, NULL, $2, $3)`,
+		app.ID, app.Status, app.UserID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record application history: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// HasApplied reports whether the user already applied to the job.
+func (r *ApplicationRepository) HasApplied(ctx context.Context, jobID, userID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM applications WHERE job_id = $1 AND user_id = $2)`, jobID, userID,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check for existing application: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *ApplicationRepository) GetApplicationByID(ctx context.Context, id uuid.UUID) (*models.Application, error) {
+	query := `SELECT id, job_id, user_id, cover_letter, resume, status, created_at, updated_at FROM applications WHERE id = $1`
+	var app models.Application
+	err := r.pool.QueryRow(ctx, query, id).Scan(
+		&app.ID, &app.JobID, &app.UserID, &app.CoverLetter, &app.Resume, &app.Status, &app.CreatedAt, &app.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("application not found")
+		}
+		return nil, fmt.Errorf("failed to get application by id: %w", err)
+	}
+	return &app, nil
+}
+
+func (r *ApplicationRepository) GetApplicationsByJobID(ctx context.Context, jobID uuid.UUID) ([]models.Application, error) {
+	query := `SELECT id, job_id, user_id, cover_letter, resume, status, created_at, updated_at FROM applications WHERE job_id = $1 ORDER BY created_at DESC`
+	rows, err := r.pool.Query(ctx, query, jobID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applications by job id: %w", err)
+	}
+	defer rows.Close()
+
+	var apps []models.Application
+	for rows.Next() {
+		var app models.Application
+		if err := rows.Scan(
+			&app.ID, &app.JobID, &app.UserID, &app.CoverLetter, &app.Resume, &app.Status, &app.CreatedAt, &app.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan application: %w", err)
+		}
+		apps = append(apps, app)
+	}
+	return apps, nil
+}
+
+func (r *ApplicationRepository) GetApplicationsByUserID(ctx context.Context, userID uuid.UUID) ([]models.Application, error) {
+	query := `SELECT id, job_id, user_id, cover_letter, resume, status, created_at, updated_at FROM applications WHERE user_id = $1 ORDER BY created_at DESC`
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applications by user id: %w", err)
+	}
+	defer rows.Close()
+
+	var apps []models.Application
+	for rows.Next() {
+		var app models.Application
+		if err := rows.Scan(
+			&app.ID, &app.JobID, &app.UserID, &app.CoverLetter, &app.Resume, &app.Status, &app.CreatedAt, &app.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan application: %w", err)
+		}
+		apps = append(apps, app)
+	}
+	return apps, nil
+}
+
+// UpdateStatus moves the application from one status to another and records the
+// transition. The update only applies if the application is still in the
+// expected status, so concurrent transitions cannot skip a step.
+func (r *ApplicationRepository) UpdateStatus(ctx context.Context, app *models.Application, from models.ApplicationStatus, actorID uuid.UUID, note string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx,
+		`UPDATE applications SET status = $1, updated_at = NOW() WHERE id = $2 AND status = $3 RETURNING updated_at`,
+		app.Status, app.ID, from,
+	).Scan(&app.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.New("application status has changed, please retry")
+		}
+		return fmt.Errorf("failed to update application status: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO application_status_history (application_id, from_status, to_status, actor_id, note) VALUES ($1, $2, $3, $4, $5)`,
+		app.ID, from, app.Status, actorID, note,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record application history: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *ApplicationRepository) GetStatusHistory(ctx context.Context, applicationID uuid.UUID) ([]models.ApplicationStatusHistory, error) {
+	query := `SELECT id, application_id, from_status, to_status, actor_id, note, created_at FROM application_status_history WHERE application_id = $1 ORDER BY created_at ASC`
+	rows, err := r.pool.Query(ctx, query, applicationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get application history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []models.ApplicationStatusHistory
+	for rows.Next() {
+		var h models.ApplicationStatusHistory
+		if err := rows.Scan(&h.ID, &h.ApplicationID, &h.FromStatus, &h.ToStatus, &h.ActorID, &h.Note, &h.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan application history: %w", err)
+		}
+		history = append(history, h)
+	}
+	return history, nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"job-portal-api/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AuditRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewAuditRepository(pool *pgxpool.Pool) *AuditRepository {
+	return &AuditRepository{pool: pool}
+}
+
+const auditEventColumns = `id, actor_id, impersonator_id, api_key_id, action, target_type, target_id, changes, ip, remote_ip, user_agent, request_id, created_at`
+
+func (r *AuditRepository) CreateEvent(ctx context.Context, e *models.AuditEvent) error {
+	query := `
+		INSERT INTO audit_events (actor_id, impersonator_id, api_key_id, action, target_type, target_id, changes, ip, remote_ip, user_agent, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at
+	`
+	var changes interface{}
+	if len(e.Changes) > 0 {
+		changes = e.Changes
+	}
+	err := r.pool.QueryRow(ctx, query,
+		e.ActorID, e.ImpersonatorID, e.APIKeyID, e.Action, e.TargetType, e.TargetID, changes, e.IP, e.RemoteIP, e.UserAgent, e.RequestID,
+	).Scan(&e.ID, &e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create audit event: %w", err)
+	}
+	return nil
+}
+
+// GetEvents returns the events matching the filter, newest first.
+func (r *AuditRepository) GetEvents(ctx context.Context, filter models.AuditFilter) (*models.AuditEventList, error) {
+	var conds []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.ActorID != nil {
+		conds = append(conds, "actor_id = "+arg(*filter.ActorID))
+	}
+	if filter.Action != "" {
+		// "user.password" matches every password action
+		conds = append(conds, "(action = "+arg(filter.Action)+" OR starts_with(action, "+arg(filter.Action+".")+"))")
+	}
+	if filter.TargetType != "" {
+		conds = append(conds, "target_type = "+arg(filter.TargetType))
+	}
+	if filter.TargetID != nil {
+		conds = append(conds, "target_id = "+arg(*filter.TargetID))
+	}
+	if filter.RequestID != "" {
+		conds = append(conds, "request_id = "+arg(filter.RequestID))
+	}
+	if filter.From != nil {
+		conds = append(conds, "created_at >= "+arg(*filter.From))
+	}
+	if filter.To != nil {
+		conds = append(conds, "created_at < "+arg(*filter.To))
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int
+	if err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM audit_events"+where, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count audit events: %w", err)
+	}
+
+	query := "SELECT " + auditEventColumns + " FROM audit_events" + where +
+		" ORDER BY created_at DESC, id DESC LIMIT " + arg(filter.Limit) + " OFFSET " + arg(filter.Offset)
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	for rows.Next() {
+		var e models.AuditEvent
+		err := rows.Scan(&e.ID, &e.ActorID, &e.ImpersonatorID, &e.APIKeyID, &e.Action, &e.TargetType, &e.TargetID,
+			&e.Changes, &e.IP, &e.RemoteIP, &e.UserAgent, &e.RequestID, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get audit events: %w", err)
+	}
+
+	return &models.AuditEventList{Items: events, Total: total}, nil
+}
@@ -0,0 +1,401 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"job-portal-api/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CompanyRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewCompanyRepository(pool *pgxpool.Pool) *CompanyRepository {
+	return &CompanyRepository{pool: pool}
+}
+
+const companyColumns = `id, name, slug, website, description, logo, verified, created_at, updated_at`
+
+func scanCompany(row pgx.Row) (models.Company, error) {
+	var company models.Company
+	err := row.Scan(
+		&company.ID, &company.Name, &company.Slug, &company.Website, &company.Description, &company.Logo, &company.Verified, &company.CreatedAt, &company.UpdatedAt,
+	)
+	return company, err
+}
+
+// CreateCompany inserts the company and makes ownerID its first owner.
+func (r *CompanyRepository) CreateCompany(ctx context.Context, company *models.Company, ownerID uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO companies (name, slug, website, description, logo)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, verified, created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, query, company.Name, company.Slug, company.Website, company.Description, company.Logo).
+		Scan(&company.ID, &company.Verified, &company.CreatedAt, &company.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return errors.New("company slug already exists")
+		}
+		return fmt.Errorf("failed to create company: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO company_members (company_id, user_id, role) VALUES ($1, $2, $3)`,
+		company.ID, ownerID, models.CompanyRoleOwner,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add company owner: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *CompanyRepository) GetCompanyByID(ctx context.Context, id uuid.UUID) (*models.Company, error) {
+	query := `SELECT ` + companyColumns + ` FROM companies WHERE id = $1`
+	company, err := scanCompany(r.pool.QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("company not found")
+		}
+		return nil, fmt.Errorf("failed to get company by id: %w", err)
+	}
+	return &company, nil
+}
+
+func (r *CompanyRepository) GetAllCompanies(ctx context.Context) ([]models.Company, error) {
+	query := `SELECT ` + companyColumns + ` FROM companies ORDER BY name`
+	rows, err := r.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all companies: %w", err)
+	}
+	defer rows.Close()
+
+	companies := []models.Company{}
+	for rows.Next() {
+		company, err := scanCompany(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan company: %w", err)
+		}
+		companies = append(companies, company)
+	}
+	return companies, nil
+}
+
+func (r *CompanyRepository) GetCompaniesByUserID(ctx context.Context, userID uuid.UUID) ([]models.Company, error) {
+	query := `
+		SELECT ` + qualifiedColumns("c", companyColumns) + `
+		FROM companies c
+		JOIN company_members m ON m.company_id = c.id
+		WHERE m.user_id = $1
+		ORDER BY c.name
+	`
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get companies by user id: %w", err)
+	}
+	defer rows.Close()
+
+	companies := []models.Company{}
+	for rows.Next() {
+		company, err := scanCompany(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan company: %w", err)
+		}
+		companies = append(companies, company)
+	}
+	return companies, nil
+}
+
+func (r *CompanyRepository) UpdateCompany(ctx context.Context, company *models.Company) error {
+	query := `
+		UPDATE companies
+		SET name = $1, slug = $2, website = $3, description = $4, logo = $5, verified = $6, updated_at = NOW()
+		WHERE id = $7
+		RETURNING updated_at
+	`
+	err := r.pool.QueryRow(ctx, query,
+		company.Name, company.Slug, company.Website, company.Description, company.Logo, company.Verified, company.ID,
+	).Scan(&company.UpdatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return errors.New("company slug already exists")
+		}
+		return fmt.Errorf("failed to update company: %w", err)
+	}
+
+	// Keep the denormalised company name and logo of its jobs in sync
+	_, err = r.pool.Exec(ctx,
+		`UPDATE jobs SET company = $1, company_logo = $2, updated_at = NOW() WHERE company_id = $3`,
+		company.Name, company.Logo, company.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update company jobs: %w", err)
+	}
+	return nil
+}
+
+func (r *CompanyRepository) DeleteCompany(ctx context.Context, id uuid.UUID) error {
+	commandTag, err := r.pool.Exec(ctx, `DELETE FROM companies WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete company: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errors.New("company not found")
+	}
+	return nil
+}
+
+// GetMemberRole returns the user's role in the company, or an empty role if
+// the user is not a member.
+func (r *CompanyRepository) GetMemberRole(ctx context.Context, companyID, userID uuid.UUID) (models.CompanyRole, error) {
+	var role models.CompanyRole
+	err := r.pool.QueryRow(ctx,
+		`SELECT role FROM company_members WHERE company_id = $1 AND user_id = $2`, companyID, userID,
+	).Scan(&role)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get company member role: %w", err)
+	}
+	return role, nil
+}
+
+func (r *CompanyRepository) GetMembers(ctx context.Context, companyID uuid.UUID) ([]models.CompanyMember, error) {
+	query := `
+		SELECT m.company_id, m.user_id, u.username, u.email, m.role, m.created_at
+		FROM company_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.company_id = $1
+		ORDER BY m.created_at
+	`
+	rows, err := r.pool.Query(ctx, query, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get company members: %w", err)
+	}
+	defer rows.Close()
+
+	members := []models.CompanyMember{}
+	for rows.Next() {
+		var m models.CompanyMember
+		if err := rows.Scan(&m.CompanyID, &m.UserID, &m.Username, &m.Email, &m.Role, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan company member: %w", err)
+		}
+		members = append(members, m)
+	}
+	return members, nil
+}
+
+// AddMember adds a user to a company. As with accepted invitations, the user's
+// own role is left alone.
+func (r *CompanyRepository) AddMember(ctx context.Context, companyID, userID uuid.UUID, role models.CompanyRole) error {
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO company_members (company_id, user_id, role) VALUES ($1, $2, $3)`, companyID, userID, role,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) {
+			switch pgErr.Code {
+			case "23505":
+				return errors.New("user is already a member of this company")
+			case "23503":
+				return errors.New("user not found")
+			}
+		}
+		return fmt.Errorf("failed to add company member: %w", err)
+	}
+	return nil
+}
+
+func (r *CompanyRepository) UpdateMemberRole(ctx context.Context, companyID, userID uuid.UUID, role models.CompanyRole) error {
+	commandTag, err := r.pool.Exec(ctx,
+		`UPDATE company_members SET role = $1 WHERE company_id = $2 AND user_id = $3`, role, companyID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update company member: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errors.New("company member not found")
+	}
+	return nil
+}
+
+func (r *CompanyRepository) RemoveMember(ctx context.Context, companyID, userID uuid.UUID) error {
+	commandTag, err := r.pool.Exec(ctx,
+		`DELETE FROM company_members WHERE company_id = $1 AND user_id = $2`, companyID, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove company member: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errors.New("company member not found")
+	}
+	return nil
+}
+
+func (r *CompanyRepository) CountOwners(ctx context.Context, companyID uuid.UUID) (int, error) {
+	var n int
+	err := r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM company_members WHERE company_id = $1 AND role = 'owner'`, companyID,
+	).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count company owners: %w", err)
+	}
+	return n, nil
+}
+
+const invitationColumns = `id, company_id, email, role, invited_by, expires_at, accepted_at, accepted_by, revoked_at, created_at`
+
+func scanInvitation(row pgx.Row) (models.CompanyInvitation, error) {
+	var inv models.CompanyInvitation
+	err := row.Scan(
+		&inv.ID, &inv.CompanyID, &inv.Email, &inv.Role, &inv.InvitedBy, &inv.ExpiresAt, &inv.AcceptedAt, &inv.AcceptedBy, &inv.RevokedAt, &inv.CreatedAt,
+	)
+	return inv, err
+}
+
+// CreateInvitation stores a new invitation, revoking any invitation still
+// pending for the same email so that only the latest token works.
+func (r *CompanyRepository) CreateInvitation(ctx context.Context, inv *models.CompanyInvitation, tokenHash string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE company_invitations SET revoked_at = NOW()
+		WHERE company_id = $1 AND LOWER(email) = LOWER($2) AND accepted_at IS NULL AND revoked_at IS NULL
+	`, inv.CompanyID, inv.Email)
+	if err != nil {
+		return fmt.Errorf("failed to revoke previous invitations: %w", err)
+	}
+
+	query := `
+		INSERT INTO company_invitations (company_id, email, role, token_hash, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+	err = tx.QueryRow(ctx, query, inv.CompanyID, inv.Email, inv.Role, tokenHash, inv.InvitedBy, inv.ExpiresAt).
+		Scan(&inv.ID, &inv.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *CompanyRepository) GetPendingInvitations(ctx context.Context, companyID uuid.UUID) ([]models.CompanyInvitation, error) {
+	query := `
+		SELECT ` + invitationColumns + ` FROM company_invitations
+		WHERE company_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at DESC
+	`
+	rows, err := r.pool.Query(ctx, query, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitations: %w", err)
+	}
+	defer rows.Close()
+
+	invitations := []models.CompanyInvitation{}
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invitation: %w", err)
+		}
+		invitations = append(invitations, inv)
+	}
+	return invitations, nil
+}
+
+func (r *CompanyRepository) RevokeInvitation(ctx context.Context, companyID, invitationID uuid.UUID) error {
+	commandTag, err := r.pool.Exec(ctx, `
+		UPDATE company_invitations SET revoked_at = NOW()
+		WHERE id = $1 AND company_id = $2 AND accepted_at IS NULL AND revoked_at IS NULL
+	`, invitationID, companyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke invitation: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errors.New("invitation not found")
+	}
+	return nil
+}
+
+// AcceptInvitation redeems the invitation matching the token hash for the
+// user, adding them to the company. The invitation row is locked so that a
+// token can only ever be redeemed once.
+func (r *CompanyRepository) AcceptInvitation(ctx context.Context, tokenHash string, userID uuid.UUID, userEmail string) (*models.CompanyInvitation, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `SELECT ` + invitationColumns + ` FROM company_invitations WHERE token_hash = $1 FOR UPDATE`
+	inv, err := scanInvitation(tx.QueryRow(ctx, query, tokenHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("invalid invitation token")
+		}
+		return nil, fmt.Errorf("failed to get invitation: %w", err)
+	}
+
+	switch {
+	case inv.AcceptedAt != nil, inv.RevokedAt != nil:
+		return nil, errors.New("invalid invitation token")
+	case time.Now().After(inv.ExpiresAt):
+		return nil, errors.New("invitation expired")
+	case !strings.EqualFold(inv.Email, userEmail):
+		return nil, errors.New("invitation was sent to a different email address")
+	}
+
+	// Joining a company leaves the user's own role alone; company jobs are
+	// governed by membership
+	_, err = tx.Exec(ctx,
+		`INSERT INTO company_members (company_id, user_id, role) VALUES ($1, $2, $3)`, inv.CompanyID, userID, inv.Role,
+	)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, errors.New("user is already a member of this company")
+		}
+		return nil, fmt.Errorf("failed to add company member: %w", err)
+	}
+
+	err = tx.QueryRow(ctx,
+		`UPDATE company_invitations SET accepted_at = NOW(), accepted_by = $1 WHERE id = $2 RETURNING accepted_at`, userID, inv.ID,
+	).Scan(&inv.AcceptedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to accept invitation: %w", err)
+	}
+	inv.AcceptedBy = &userID
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &inv, nil
+}
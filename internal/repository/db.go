@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
@@ -47,3 +48,13 @@ func RunMigrations(connStr string) error {
 	log.Println("Migrations applied successfully")
 	return nil
 }
+
+// qualifiedColumns prefixes every column of a comma separated list with the
+// table alias, for queries where the bare names would be ambiguous.
+func qualifiedColumns(alias, columns string) string {
+	cols := strings.Split(columns, ", ")
+	for i, col := range cols {
+		cols[i] = alias + "." + col
+	}
+	return strings.Join(cols, ", ")
+}
@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type EmailVerificationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewEmailVerificationRepository(pool *pgxpool.Pool) *EmailVerificationRepository {
+	return &EmailVerificationRepository{pool: pool}
+}
+
+// CreateVerification stores a verification token for the email, invalidating
+// earlier unused tokens of the user so that only the latest one works.
+func (r *EmailVerificationRepository) CreateVerification(ctx context.Context, userID uuid.UUID, email, tokenHash string, expiresAt time.Time) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE email_verifications SET expires_at = NOW() WHERE user_id = $1 AND used_at IS NULL AND expires_at > NOW()`, userID,
+	); err != nil {
+		return fmt.Errorf("failed to invalidate verifications: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO email_verifications (user_id, email, token_hash, expires_at) VALUES ($1, $2, $3, $4)`,
+		userID, email, tokenHash, expiresAt,
+	); err != nil {
+		return fmt.Errorf("failed to create verification: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// VerifyEmail consumes the token and marks the user's email as verified. The
+// token only counts if the user still has the address it was sent to.
+func (r *EmailVerificationRepository) VerifyEmail(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var id, userID uuid.UUID
+	var email string
+	var expiresAt time.Time
+	var usedAt *time.Time
+	err = tx.QueryRow(ctx,
+		`SELECT id, user_id, email, expires_at, used_at FROM email_verifications WHERE token_hash = $1 FOR UPDATE`, tokenHash,
+	).Scan(&id, &userID, &email, &expiresAt, &usedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, errors.New("invalid verification token")
+		}
+		return uuid.Nil, fmt.Errorf("failed to get verification: %w", err)
+	}
+
+	if usedAt != nil {
+		return uuid.Nil, errors.New("invalid verification token")
+	}
+	if time.Now().After(expiresAt) {
+		return uuid.Nil, errors.New("verification token expired")
+	}
+
+	commandTag, err := tx.Exec(ctx,
+		`UPDATE users SET email_verified_at = NOW(), updated_at = NOW() WHERE id = $1 AND email = $2`, userID, email,
+	)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to verify email: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return uuid.Nil, errors.New("invalid verification token")
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE email_verifications SET used_at = NOW() WHERE id = $1`, id); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to update verification: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return userID, nil
+}
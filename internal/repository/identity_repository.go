@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"job-portal-api/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IdentityRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewIdentityRepository(pool *pgxpool.Pool) *IdentityRepository {
+	return &IdentityRepository{pool: pool}
+}
+
+const identityColumns = `id, user_id, provider, subject, email, created_at, last_login_at`
+
+func scanIdentity(row pgx.Row) (models.UserIdentity, error) {
+	var i models.UserIdentity
+	err := row.Scan(&i.ID, &i.UserID, &i.Provider, &i.Subject, &i.Email, &i.CreatedAt, &i.LastLoginAt)
+	return i, err
+}
+
+// CreateLoginState stores an authorization request under the hash of its
+// state. Expired requests are cleared on the way.
+func (r *IdentityRepository) CreateLoginState(ctx context.Context, stateHash string, state *models.OIDCLoginState) error {
+	if _, err := r.pool.Exec(ctx, `DELETE FROM oidc_login_states WHERE expires_at < NOW()`); err != nil {
+		return fmt.Errorf("failed to delete expired login states: %w", err)
+	}
+
+	_, err := r.pool.Exec(ctx,
+		`INSERT INTO oidc_login_states (state_hash, provider, code_verifier, nonce, user_id, expires_at) VALUES ($1, $2, $3, $4, $5, $6)`,
+		stateHash, state.Provider, state.CodeVerifier, state.Nonce, state.UserID, state.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create login state: %w", err)
+	}
+	return nil
+}
+
+// ConsumeLoginState removes and returns the authorization request with the
+// given state hash, so that it can't be completed twice.
+func (r *IdentityRepository) ConsumeLoginState(ctx context.Context, stateHash string) (*models.OIDCLoginState, error) {
+	query := `
+		DELETE FROM oidc_login_states WHERE state_hash = $1
+		RETURNING provider, code_verifier, nonce, user_id, expires_at
+	`
+	var state models.OIDCLoginState
+	err := r.pool.QueryRow(ctx, query, stateHash).
+		Scan(&state.Provider, &state.CodeVerifier, &state.Nonce, &state.UserID, &state.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("invalid state")
+		}
+		return nil, fmt.Errorf("failed to get login state: %w", err)
+	}
+	if time.Now().After(state.ExpiresAt) {
+		return nil, errors.New("invalid state")
+	}
+	return &state, nil
+}
+
+func (r *IdentityRepository) GetIdentity(ctx context.Context, provider, subject string) (*models.UserIdentity, error) {
+	query := `SELECT ` + identityColumns + ` FROM user_identities WHERE provider = $1 AND subject = $2`
+	identity, err := scanIdentity(r.pool.QueryRow(ctx, query, provider, subject))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("identity not found")
+		}
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+	return &identity, nil
+}
+
+func (r *IdentityRepository) GetIdentitiesByUserID(ctx context.Context, userID uuid.UUID) ([]models.UserIdentity, error) {
+	query := `SELECT ` + identityColumns + ` FROM user_identities WHERE user_id = $1 ORDER BY created_at`
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identities: %w", err)
+	}
+	defer rows.Close()
+
+	identities := []models.UserIdentity{}
+	for rows.Next() {
+		identity, err := scanIdentity(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan identity: %w", err)
+		}
+		identities = append(identities, identity)
+	}
+	return identities, rows.Err()
+}
+
+// CreateIdentity links an identity to an existing user.
+func (r *IdentityRepository) CreateIdentity(ctx context.Context, identity *models.UserIdentity) error {
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject, email, last_login_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	err := r.pool.QueryRow(ctx, query, identity.UserID, identity.Provider, identity.Subject, identity.Email, identity.LastLoginAt).
+		Scan(&identity.ID, &identity.CreatedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return errors.New("identity already linked")
+		}
+		return fmt.Errorf("failed to create identity: %w", err)
+	}
+	return nil
+}
+
+// CreateUserWithIdentity creates a user whose email the provider has
+// verified, together with the identity they signed up with.
+func (r *IdentityRepository) CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.UserIdentity) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO users (username, email, password, role, email_verified_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, created_at, updated_at, profile_picture, email_verified_at
+	`
+	err = tx.QueryRow(ctx, query, user.Username, user.Email, user.Password, user.Role).
+		Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt, &user.ProfilePicture, &user.EmailVerifiedAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			switch pgErr.ConstraintName {
+			case "users_username_key":
+				return errors.New("username already exists")
+			case "users_email_key":
+				return errors.New("email already registered")
+			}
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	identity.UserID = user.ID
+	err = tx.QueryRow(ctx,
+		`INSERT INTO user_identities (user_id, provider, subject, email, last_login_at) VALUES ($1, $2, $3, $4, NOW()) RETURNING id, created_at, last_login_at`,
+		identity.UserID, identity.Provider, identity.Subject, identity.Email,
+	).Scan(&identity.ID, &identity.CreatedAt, &identity.LastLoginAt)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return errors.New("identity already linked")
+		}
+		return fmt.Errorf("failed to create identity: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// TouchIdentity records a login with the identity and the email the provider
+// currently reports for it.
+func (r *IdentityRepository) TouchIdentity(ctx context.Context, id uuid.UUID, email string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE user_identities SET last_login_at = NOW(), email = $2 WHERE id = $1`, id, email)
+	if err != nil {
+		return fmt.Errorf("failed to update identity: %w", err)
+	}
+	return nil
+}
+
+func (r *IdentityRepository) DeleteIdentity(ctx context.Context, id, userID uuid.UUID) error {
+	commandTag, err := r.pool.Exec(ctx, `DELETE FROM user_identities WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete identity: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errors.New("identity not found")
+	}
+	return nil
+}
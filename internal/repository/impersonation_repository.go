@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"job-portal-api/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ImpersonationRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewImpersonationRepository(pool *pgxpool.Pool) *ImpersonationRepository {
+	return &ImpersonationRepository{pool: pool}
+}
+
+func (r *ImpersonationRepository) CreateRequest(ctx context.Context, req *models.ImpersonatedRequest) error {
+	query := `
+		INSERT INTO impersonation_requests (impersonator_id, user_id, session_id, method, path, status, ip, remote_ip, user_agent)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at
+	`
+	err := r.pool.QueryRow(ctx, query, req.ImpersonatorID, req.UserID, req.SessionID, req.Method, req.Path, req.Status, req.IP, req.RemoteIP, req.UserAgent).
+		Scan(&req.ID, &req.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record impersonated request: %w", err)
+	}
+	return nil
+}
@@ -2,10 +2,18 @@ package repository
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"job-portal-api/internal/models"
+	"job-portal-api/internal/policy"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -17,14 +25,73 @@ func NewJobRepository(pool *pgxpool.Pool) *JobRepository {
 	return &JobRepository{pool: pool}
 }
 
+// jobColumns is the column list matching the scan order of jobScanFields.
+const jobColumns = `id, title, description, location, salary, salary_min, salary_max, salary_currency, salary_period, experience_level, skills, job_type, company, company_id, company_logo, status, published_at, publish_at, expires_at, created_at, updated_at, user_id`
+
+func jobScanFields(job *models.Job) []interface{} {
+	return []interface{}{
+		&job.ID, &job.Title, &job.Description, &job.Location, &job.Salary, &job.SalaryMin, &job.SalaryMax, &job.SalaryCurrency, &job.SalaryPeriod, &job.ExperienceLevel, &job.Skills, &job.JobType, &job.Company, &job.CompanyID, &job.CompanyLogo, &job.Status, &job.PublishedAt, &job.PublishAt, &job.ExpiresAt, &job.CreatedAt, &job.UpdatedAt, &job.UserID,
+	}
+}
+
+func scanJob(row pgx.Row) (models.Job, error) {
+	var job models.Job
+	err := row.Scan(jobScanFields(&job)...)
+	return job, err
+}
+
+// openJobCond matches jobs that are publicly visible.
+const openJobCond = `(status = 'published' AND (expires_at IS NULL OR expires_at > NOW()))`
+
+// jobVisibilityCond restricts a query to the jobs the viewer may see: open
+// jobs, the viewer's own jobs and the jobs of companies the viewer belongs to.
+// arg binds the viewer's ID. It returns an empty string for viewers allowed to
+// read any job.
+func jobVisibilityCond(viewer *models.User, arg func(interface{}) string) string {
+	if viewer == nil {
+		return openJobCond
+	}
+	if policy.Can(viewer, policy.JobsReadAny) {
+		return ""
+	}
+	id := arg(viewer.ID)
+	return "(" + openJobCond + " OR user_id = " + id +
+		" OR company_id IN (SELECT company_id FROM company_members WHERE user_id = " + id + "))"
+}
+
+// jobCursor identifies the last job of a page for keyset pagination.
+type jobCursor struct {
+	CreatedAt time.Time `json:"c"`
+	Title     string    `json:"t"`
+	ID        uuid.UUID `json:"i"`
+}
+
+func encodeJobCursor(c jobCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeJobCursor(s string) (jobCursor, error) {
+	var c jobCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, errors.New("invalid cursor")
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return c, errors.New("invalid cursor")
+	}
+	return c, nil
+}
+
 func (r *JobRepository) CreateJob(ctx context.Context, job *models.Job) error {
 	query := `
-		INSERT INTO jobs (title, description, location, salary, experience_level, skills, job_type, company, company_logo, user_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO jobs (title, description, location, salary, salary_min, salary_max, salary_currency, salary_period, experience_level, skills, job_type, company, company_id, company_logo, status, published_at, publish_at, expires_at, user_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
 		RETURNING id, created_at, updated_at
 	`
 	err := r.pool.QueryRow(ctx, query,
-		job.Title, job.Description, job.Location, job.Salary, job.ExperienceLevel, job.Skills, job.JobType, job.Company, job.CompanyLogo, job.UserID,
+		job.Title, job.Description, job.Location, job.Salary, job.SalaryMin, job.SalaryMax, job.SalaryCurrency, job.SalaryPeriod, job.ExperienceLevel, job.Skills, job.JobType, job.Company, job.CompanyID, job.CompanyLogo,
+		job.Status, job.PublishedAt, job.PublishAt, job.ExpiresAt, job.UserID,
 	).Scan(&job.ID, &job.CreatedAt, &job.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create job: %w", err)
@@ -32,29 +99,247 @@ func (r *JobRepository) CreateJob(ctx context.Context, job *models.Job) error {
 	return nil
 }
 
-func (r *JobRepository) GetAllJobs(ctx context.Context) ([]models.Job, error) {
-	query := `SELECT id, title, description, location, salary, experience_level, skills, job_type, company, company_logo, created_at, updated_at, user_id FROM jobs`
-	rows, err := r.pool.Query(ctx, query)
+func (r *JobRepository) GetAllJobs(ctx context.Context, filter models.JobFilter) (*models.JobList, error) {
+	var conds []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if filter.Location != "" {
+		conds = append(conds, "location ILIKE "+arg("%"+likeEscaper.Replace(filter.Location)+"%"))
+	}
+	if filter.JobType != "" {
+		conds = append(conds, "LOWER(job_type) = LOWER("+arg(filter.JobType)+")")
+	}
+	if filter.ExperienceLevel != "" {
+		conds = append(conds, "LOWER(experience_level) = LOWER("+arg(filter.ExperienceLevel)+")")
+	}
+	if filter.Company != "" {
+		conds = append(conds, "company ILIKE "+arg("%"+likeEscaper.Replace(filter.Company)+"%"))
+	}
+	if len(filter.Skills) > 0 {
+		if filter.SkillsMatch == "all" {
+			conds = append(conds, "skills @> "+arg(filter.Skills))
+		} else {
+			conds = append(conds, "skills && "+arg(filter.Skills))
+		}
+	}
+	if filter.CreatedAfter != nil {
+		conds = append(conds, "created_at > "+arg(*filter.CreatedAfter))
+	}
+	if filter.SalaryMin != nil {
+		conds = append(conds, "COALESCE(salary_max, salary_min) >= "+arg(*filter.SalaryMin))
+	}
+	if filter.SalaryMax != nil {
+		conds = append(conds, "COALESCE(salary_min, salary_max) <= "+arg(*filter.SalaryMax))
+	}
+	if filter.SalaryCurrency != "" {
+		conds = append(conds, "salary_currency = "+arg(filter.SalaryCurrency))
+	}
+	if filter.SalaryPeriod != "" {
+		conds = append(conds, "salary_period = "+arg(filter.SalaryPeriod))
+	}
+	if cond := jobVisibilityCond(filter.Viewer, arg); cond != "" {
+		conds = append(conds, cond)
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var total int
+	if err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM jobs"+where, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %w", err)
+	}
+
+	var orderBy string
+	switch filter.Sort {
+	case "oldest":
+		orderBy = "created_at ASC, id ASC"
+	case "title":
+		orderBy = "title ASC, id ASC"
+	default:
+		orderBy = "created_at DESC, id DESC"
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := decodeJobCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		var cond string
+		switch filter.Sort {
+		case "oldest":
+			cond = fmt.Sprintf("(created_at, id) > (%s, %s)", arg(cursor.CreatedAt), arg(cursor.ID))
+		case "title":
+			cond = fmt.Sprintf("(title, id) > (%s, %s)", arg(cursor.Title), arg(cursor.ID))
+		default:
+			cond = fmt.Sprintf("(created_at, id) < (%s, %s)", arg(cursor.CreatedAt), arg(cursor.ID))
+		}
+		if where == "" {
+			where = " WHERE " + cond
+		} else {
+			where += " AND " + cond
+		}
+	}
+
+	// Fetch one extra row to know whether another page follows
+	query := "SELECT " + jobColumns + " FROM jobs" + where + " ORDER BY " + orderBy + " LIMIT " + arg(filter.Limit+1)
+	if filter.Cursor == "" && filter.Offset > 0 {
+		query += " OFFSET " + arg(filter.Offset)
+	}
+
+	rows, err := r.pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all jobs: %w", err)
 	}
 	defer rows.Close()
 
-	var jobs []models.Job
+	jobs := []models.Job{}
 	for rows.Next() {
-		var job models.Job
-		if err := rows.Scan(
-			&job.ID, &job.Title, &job.Description, &job.Location, &job.Salary, &job.ExperienceLevel, &job.Skills, &job.JobType, &job.Company, &job.CompanyLogo, &job.CreatedAt, &job.UpdatedAt, &job.UserID,
-		); err != nil {
+		job, err := scanJob(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan job: %w", err)
 		}
 		jobs = append(jobs, job)
 	}
-	return jobs, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get all jobs: %w", err)
+	}
+
+	list := &models.JobList{Items: jobs, Total: total}
+	if len(jobs) > filter.Limit {
+		list.Items = jobs[:filter.Limit]
+		last := list.Items[len(list.Items)-1]
+		list.NextCursor = encodeJobCursor(jobCursor{CreatedAt: last.CreatedAt, Title: last.Title, ID: last.ID})
+	}
+	return list, nil
+}
+
+// SearchJobs ranks jobs matching the query across title, company, skills and
+// description, highlighting the matched terms of the description.
+func (r *JobRepository) SearchJobs(ctx context.Context, q string, limit, offset int, viewer *models.User) (*models.JobSearchList, error) {
+	args := []interface{}{q}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where := "search_vector @@ websearch_to_tsquery('english', $1)"
+	if cond := jobVisibilityCond(viewer, arg); cond != "" {
+		where += " AND " + cond
+	}
+
+	var total int
+	if err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM jobs WHERE "+where, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	query := `
+		SELECT ` + jobColumns + `,
+			ts_rank_cd(search_vector, websearch_to_tsquery('english', $1))::float8 AS score,
+			ts_headline('english', description, websearch_to_tsquery('english', $1), ` + arg(headlineOptions) + `) AS snippet
+		FROM jobs
+		WHERE ` + where + `
+		ORDER BY score DESC, created_at DESC, id DESC
+		LIMIT ` + arg(limit) + ` OFFSET ` + arg(offset)
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search jobs: %w", err)
+	}
+	defer rows.Close()
+
+	results := []models.JobSearchResult{}
+	for rows.Next() {
+		var result models.JobSearchResult
+		if err := rows.Scan(append(jobScanFields(&result.Job), &result.Score, &result.Snippet)...); err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		result.Snippet = markHeadline(result.Snippet)
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search jobs: %w", err)
+	}
+
+	return &models.JobSearchList{Items: results, Total: total}, nil
+}
+
+// Matches in headlines are delimited by private use characters, which
+// markHeadline turns into <mark> tags once the rest is HTML escaped. The
+// description is employer input, so it must never reach clients unescaped.
+const (
+	headlineStart   = "\uE000"
+	headlineStop    = "\uE001"
+	headlineOptions = "StartSel=" + headlineStart + ", StopSel=" + headlineStop + ", MaxFragments=2, MaxWords=30, MinWords=10"
+)
+
+var headlineMarker = strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>")
+
+// markHeadline HTML escapes a ts_headline snippet and highlights its matches.
+func markHeadline(snippet string) string {
+	return headlineMarker.Replace(html.EscapeString(snippet))
+}
+
+// likeEscaper escapes LIKE wildcards in user input.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SuggestJobValues returns the distinct values of open jobs' field that are
+// close to the prefix, tolerating typos through trigram word similarity. Exact
+// prefix matches rank first.
+func (r *JobRepository) SuggestJobValues(ctx context.Context, field, prefix string, limit int) ([]models.JobSuggestion, error) {
+	from, col, prefilter := "jobs", "", ""
+	switch field {
+	case "title", "company", "location":
+		col = field
+	case "skill":
+		from = "jobs, unnest(skills) AS skill"
+		col = "skill"
+		prefilter = "($1 <% jobs_skills_to_text(skills) OR jobs_skills_to_text(skills) ILIKE $4) AND "
+	default:
+		return nil, errors.New("invalid suggestion field")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %[1]s, COUNT(*)
+		FROM %[2]s
+		WHERE %[3]s($1 <%% %[1]s OR %[1]s ILIKE $2) AND %[4]s
+		GROUP BY %[1]s
+		ORDER BY (%[1]s ILIKE $2) DESC, word_similarity($1, %[1]s) DESC, COUNT(*) DESC, %[1]s
+		LIMIT $3
+	`, col, from, prefilter, openJobCond)
+
+	escaped := likeEscaper.Replace(prefix)
+	args := []interface{}{prefix, escaped + "%", limit}
+	if prefilter != "" {
+		args = append(args, "%"+escaped+"%")
+	}
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest job values: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := []models.JobSuggestion{}
+	for rows.Next() {
+		var suggestion models.JobSuggestion
+		if err := rows.Scan(&suggestion.Value, &suggestion.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan suggestion: %w", err)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to suggest job values: %w", err)
+	}
+	return suggestions, nil
 }
 
 func (r *JobRepository) GetJobsByUserID(ctx context.Context, userID uuid.UUID) ([]models.Job, error) {
-	query := `SELECT id, title, description, location, salary, experience_level, skills, job_type, company, company_logo, created_at, updated_at, user_id FROM jobs WHERE user_id = $1`
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE user_id = $1`
 	rows, err := r.pool.Query(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get jobs by user id: %w", err)
@@ -63,10 +348,8 @@ func (r *JobRepository) GetJobsByUserID(ctx context.Context, userID uuid.UUID) (
 
 	var jobs []models.Job
 	for rows.Next() {
-		var job models.Job
-		if err := rows.Scan(
-			&job.ID, &job.Title, &job.Description, &job.Location, &job.Salary, &job.ExperienceLevel, &job.Skills, &job.JobType, &job.Company, &job.CompanyLogo, &job.CreatedAt, &job.UpdatedAt, &job.UserID,
-		); err != nil {
+		job, err := scanJob(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan job: %w", err)
 		}
 		jobs = append(jobs, job)
@@ -75,12 +358,12 @@ func (r *JobRepository) GetJobsByUserID(ctx context.Context, userID uuid.UUID) (
 }
 
 func (r *JobRepository) GetJobByID(ctx context.Context, id uuid.UUID) (*models.Job, error) {
-	query := `SELECT id, title, description, location, salary, experience_level, skills, job_type, company, company_logo, created_at, updated_at, user_id FROM jobs WHERE id = $1`
-	var job models.Job
-	err := r.pool.QueryRow(ctx, query, id).Scan(
-		&job.ID, &job.Title, &job.Description, &job.Location, &job.Salary, &job.ExperienceLevel, &job.Skills, &job.JobType, &job.Company, &job.CompanyLogo, &job.CreatedAt, &job.UpdatedAt, &job.UserID,
-	)
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE id = $1`
+	job, err := scanJob(r.pool.QueryRow(ctx, query, id))
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("job not found")
+		}
 		return nil, fmt.Errorf("failed to get job by id: %w", err)
 	}
 	return &job, nil
@@ -89,12 +372,14 @@ func (r *JobRepository) GetJobByID(ctx context.Context, id uuid.UUID) (*models.J
 func (r *JobRepository) UpdateJob(ctx context.Context, job *models.Job) error {
 	query := `
 		UPDATE jobs
-		SET title = $1, description = $2, location = $3, salary = $4, experience_level = $5, skills = $6, job_type = $7, company = $8, company_logo = $9, updated_at = NOW()
-		WHERE id = $10
+		SET title = $1, description = $2, location = $3, salary = $4, salary_min = $5, salary_max = $6, salary_currency = $7, salary_period = $8,
+			experience_level = $9, skills = $10, job_type = $11, company = $12, company_id = $13, company_logo = $14, publish_at = $15, expires_at = $16, updated_at = NOW()
+		WHERE id = $17
 		RETURNING updated_at
 	`
 	err := r.pool.QueryRow(ctx, query,
-		job.Title, job.Description, job.Location, job.Salary, job.ExperienceLevel, job.Skills, job.JobType, job.Company, job.CompanyLogo, job.ID,
+		job.Title, job.Description, job.Location, job.Salary, job.SalaryMin, job.SalaryMax, job.SalaryCurrency, job.SalaryPeriod, job.ExperienceLevel, job.Skills, job.JobType, job.Company, job.CompanyID, job.CompanyLogo,
+		job.PublishAt, job.ExpiresAt, job.ID,
 	).Scan(&job.UpdatedAt)
 
 	if err != nil {
@@ -114,3 +399,84 @@ func (r *JobRepository) DeleteJob(ctx context.Context, id uuid.UUID) error {
 	}
 	return nil
 }
+
+func (r *JobRepository) UpdateJobStatus(ctx context.Context, job *models.Job) error {
+	query := `
+		UPDATE jobs
+		SET status = $1, published_at = $2, publish_at = $3, expires_at = $4, updated_at = NOW()
+		WHERE id = $5
+		RETURNING updated_at
+	`
+	err := r.pool.QueryRow(ctx, query, job.Status, job.PublishedAt, job.PublishAt, job.ExpiresAt, job.ID).Scan(&job.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update job status: %w", err)
+	}
+	return nil
+}
+
+// ExpireJobs flips every published or paused job past its expiry date to
+// expired and returns how many jobs were affected.
+func (r *JobRepository) ExpireJobs(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE jobs
+		SET status = 'expired', updated_at = NOW()
+		WHERE status IN ('published', 'paused') AND expires_at <= NOW()
+	`
+	commandTag, err := r.pool.Exec(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire jobs: %w", err)
+	}
+	return commandTag.RowsAffected(), nil
+}
+
+// PublishScheduledJobs publishes up to limit drafts whose publish_at has
+// passed and returns them. Drafts whose expires_at has passed as well are
+// expired instead, so the owner can publish them again with a new date. Rows
+// are claimed with FOR UPDATE SKIP LOCKED so that several replicas running
+// the scheduler never publish the same job twice.
+func (r *JobRepository) PublishScheduledJobs(ctx context.Context, limit int) ([]models.Job, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		WITH due AS (
+			SELECT id FROM jobs
+			WHERE status = 'draft' AND publish_at <= NOW()
+			ORDER BY publish_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE jobs
+		SET status = CASE WHEN jobs.expires_at <= NOW() THEN 'expired' ELSE 'published' END,
+			published_at = CASE WHEN jobs.expires_at <= NOW() THEN jobs.published_at ELSE COALESCE(jobs.published_at, NOW()) END,
+			publish_at = NULL, updated_at = NOW()
+		FROM due
+		WHERE jobs.id = due.id
+		RETURNING ` + qualifiedColumns("jobs", jobColumns)
+	rows, err := tx.Query(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish scheduled jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []models.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to publish scheduled jobs: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return jobs, nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"job-portal-api/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type MFARepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewMFARepository(pool *pgxpool.Pool) *MFARepository {
+	return &MFARepository{pool: pool}
+}
+
+func (r *MFARepository) GetMFA(ctx context.Context, userID uuid.UUID) (*models.UserMFA, error) {
+	query := `SELECT user_id, secret, enabled_at, last_used_step, created_at FROM user_mfa WHERE user_id = $1`
+	var m models.UserMFA
+	err := r.pool.QueryRow(ctx, query, userID).Scan(&m.UserID, &m.Secret, &m.EnabledAt, &m.LastUsedStep, &m.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("mfa not found")
+		}
+		return nil, fmt.Errorf("failed to get mfa: %w", err)
+	}
+	return &m, nil
+}
+
+// StartEnrollment stores a new secret for the user, replacing a pending
+// enrollment. It fails with "mfa already enabled" once enrollment has been
+// confirmed.
+func (r *MFARepository) StartEnrollment(ctx context.Context, userID uuid.UUID, secret string) error {
+	query := `
+		INSERT INTO user_mfa (user_id, secret) VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW()
+		WHERE user_mfa.enabled_at IS NULL
+	`
+	commandTag, err := r.pool.Exec(ctx, query, userID, secret)
+	if err != nil {
+		return fmt.Errorf("failed to start mfa enrollment: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errors.New("mfa already enabled")
+	}
+	return nil
+}
+
+// EnableMFA confirms a pending enrollment and stores its recovery codes.
+func (r *MFARepository) EnableMFA(ctx context.Context, userID uuid.UUID, recoveryCodeHashes []string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	commandTag, err := tx.Exec(ctx, `UPDATE user_mfa SET enabled_at = NOW() WHERE user_id = $1 AND enabled_at IS NULL`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to enable mfa: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errors.New("mfa already enabled")
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes discards the user's recovery codes in favour of new
+// ones.
+func (r *MFARepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID uuid.UUID, codeHashes []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		`INSERT INTO mfa_recovery_codes (user_id, code_hash) SELECT $1, unnest($2::text[])`, userID, codeHashes,
+	); err != nil {
+		return fmt.Errorf("failed to create recovery codes: %w", err)
+	}
+	return nil
+}
+
+// CountRecoveryCodes returns how many unused recovery codes the user has.
+func (r *MFARepository) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := r.pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL`, userID,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+	}
+	return count, nil
+}
+
+// UseRecoveryCode consumes one of the user's unused recovery codes.
+func (r *MFARepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error {
+	commandTag, err := r.pool.Exec(ctx,
+		`UPDATE mfa_recovery_codes SET used_at = NOW() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`,
+		userID, codeHash,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errors.New("invalid code")
+	}
+	return nil
+}
+
+// UseStep records that a TOTP code of the given time step was accepted. It
+// fails with "invalid code" if that step or a later one was used before.
+func (r *MFARepository) UseStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	commandTag, err := r.pool.Exec(ctx,
+		`UPDATE user_mfa SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`, userID, step,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update mfa: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errors.New("invalid code")
+	}
+	return nil
+}
+
+// DeleteMFA removes the user's enrollment and recovery codes.
+func (r *MFARepository) DeleteMFA(ctx context.Context, userID uuid.UUID) error {
+	commandTag, err := r.pool.Exec(ctx, `DELETE FROM user_mfa WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete mfa: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errors.New("mfa not found")
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RateLimitRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRateLimitRepository(pool *pgxpool.Pool) *RateLimitRepository {
+	return &RateLimitRepository{pool: pool}
+}
+
+// Hit counts one event for key in the current fixed window and returns the
+// number of events in that window so far.
+func (r *RateLimitRepository) Hit(ctx context.Context, key string, window time.Duration) (int, error) {
+	query := `
+		INSERT INTO rate_limits (key, window_start, count) VALUES ($1, NOW(), 1)
+		ON CONFLICT (key) DO UPDATE SET
+			count = CASE WHEN rate_limits.window_start <= NOW() - $2::interval THEN 1 ELSE rate_limits.count + 1 END,
+			window_start = CASE WHEN rate_limits.window_start <= NOW() - $2::interval THEN NOW() ELSE rate_limits.window_start END
+		RETURNING count
+	`
+	var count int
+	if err := r.pool.QueryRow(ctx, query, key, window).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to record rate limit hit: %w", err)
+	}
+	return count, nil
+}
+
+// Count returns the number of events for key in the current window without
+// counting a new one.
+func (r *RateLimitRepository) Count(ctx context.Context, key string, window time.Duration) (int, error) {
+	query := `SELECT count FROM rate_limits WHERE key = $1 AND window_start > NOW() - $2::interval`
+	var count int
+	if err := r.pool.QueryRow(ctx, query, key, window).Scan(&count); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get rate limit count: %w", err)
+	}
+	return count, nil
+}
+
+// DeleteStale removes counters whose window started before olderThan ago,
+// and the failed logins of unknown emails that are as old and not locked.
+func (r *RateLimitRepository) DeleteStale(ctx context.Context, olderThan time.Duration) (int64, error) {
+	commandTag, err := r.pool.Exec(ctx, `DELETE FROM rate_limits WHERE window_start < NOW() - $1::interval`, olderThan)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete rate limits: %w", err)
+	}
+	deleted := commandTag.RowsAffected()
+
+	commandTag, err = r.pool.Exec(ctx, `
+		DELETE FROM unknown_login_attempts
+		WHERE last_failed_login_at < NOW() - $1::interval AND (locked_until IS NULL OR locked_until <= NOW())
+	`, olderThan)
+	if err != nil {
+		return deleted, fmt.Errorf("failed to delete login attempts: %w", err)
+	}
+	return deleted + commandTag.RowsAffected(), nil
+}
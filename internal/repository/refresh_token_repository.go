@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"job-portal-api/internal/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RefreshTokenRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRefreshTokenRepository(pool *pgxpool.Pool) *RefreshTokenRepository {
+	return &RefreshTokenRepository{pool: pool}
+}
+
+const refreshTokenColumns = `id, user_id, family_id, expires_at, rotated_at, replaced_by, revoked_at, created_at`
+
+func scanRefreshToken(row pgx.Row) (models.RefreshToken, error) {
+	var t models.RefreshToken
+	err := row.Scan(&t.ID, &t.UserID, &t.FamilyID, &t.ExpiresAt, &t.RotatedAt, &t.ReplacedBy, &t.RevokedAt, &t.CreatedAt)
+	return t, err
+}
+
+func (r *RefreshTokenRepository) CreateRefreshToken(ctx context.Context, t *models.RefreshToken, tokenHash string) error {
+	query := `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`
+	err := r.pool.QueryRow(ctx, query, t.UserID, t.FamilyID, tokenHash, t.ExpiresAt).Scan(&t.ID, &t.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+	return nil
+}
+
+// revokeFamilyQuery revokes a token family and the session it belongs to.
+const revokeFamilyQuery = `
+	WITH session AS (
+		UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL
+	)
+	UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL
+`
+
+// RotateRefreshToken exchanges the token identified by tokenHash for next,
+// which joins the same family. Presenting a token that was already rotated
+// means it leaked, so the whole family is revoked.
+func (r *RefreshTokenRepository) RotateRefreshToken(ctx context.Context, tokenHash string, next *models.RefreshToken, nextHash string) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `SELECT ` + refreshTokenColumns + ` FROM refresh_tokens WHERE token_hash = $1 FOR UPDATE`
+	current, err := scanRefreshToken(tx.QueryRow(ctx, query, tokenHash))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.New("invalid refresh token")
+		}
+		return fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	switch {
+	case current.RevokedAt != nil:
+		return errors.New("invalid refresh token")
+	case current.RotatedAt != nil:
+		if _, err := tx.Exec(ctx, revokeFamilyQuery, current.FamilyID); err != nil {
+			return fmt.Errorf("failed to revoke refresh token family: %w", err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return errors.New("refresh token reuse detected")
+	case time.Now().After(current.ExpiresAt):
+		return errors.New("refresh token expired")
+	}
+
+	next.UserID = current.UserID
+	next.FamilyID = current.FamilyID
+	err = tx.QueryRow(ctx,
+		`INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at) VALUES ($1, $2, $3, $4) RETURNING id, created_at`,
+		next.UserID, next.FamilyID, nextHash, next.ExpiresAt,
+	).Scan(&next.ID, &next.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create refresh token: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE refresh_tokens SET rotated_at = NOW(), replaced_by = $1 WHERE id = $2`, next.ID, current.ID,
+	); err != nil {
+		return fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RevokeRefreshTokenFamily revokes every token in the family of the token
+// identified by tokenHash, ending its session. It returns the token with just
+// its user and family set, or nil if there is no such token.
+func (r *RefreshTokenRepository) RevokeRefreshTokenFamily(ctx context.Context, tokenHash string) (*models.RefreshToken, error) {
+	var rt models.RefreshToken
+	err := r.pool.QueryRow(ctx, `SELECT user_id, family_id FROM refresh_tokens WHERE token_hash = $1`, tokenHash).Scan(&rt.UserID, &rt.FamilyID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+
+	if _, err := r.pool.Exec(ctx, revokeFamilyQuery, rt.FamilyID); err != nil {
+		return nil, fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+	return &rt, nil
+}
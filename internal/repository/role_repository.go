@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/policy"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RoleRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewRoleRepository(pool *pgxpool.Pool) *RoleRepository {
+	return &RoleRepository{pool: pool}
+}
+
+// GetRolePermissions returns the permissions granted to each role.
+func (r *RoleRepository) GetRolePermissions(ctx context.Context) (map[models.Role][]policy.Permission, error) {
+	rows, err := r.pool.Query(ctx, `SELECT role, permission FROM role_permissions ORDER BY role, permission`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query role permissions: %w", err)
+	}
+	defer rows.Close()
+
+	perms := make(map[models.Role][]policy.Permission)
+	for rows.Next() {
+		var role models.Role
+		var perm policy.Permission
+		if err := rows.Scan(&role, &perm); err != nil {
+			return nil, fmt.Errorf("failed to scan role permission: %w", err)
+		}
+		perms[role] = append(perms[role], perm)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate role permissions: %w", err)
+	}
+	return perms, nil
+}
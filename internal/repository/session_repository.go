@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"job-portal-api/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SessionRepository struct {
+	pool *pgxpool.Pool
+}
+
+func NewSessionRepository(pool *pgxpool.Pool) *SessionRepository {
+	return &SessionRepository{pool: pool}
+}
+
+const sessionColumns = `id, user_id, device, ip, user_agent, created_at, last_seen_at, expires_at, revoked_at, impersonator_id`
+
+func scanSession(row pgx.Row) (models.Session, error) {
+	var s models.Session
+	err := row.Scan(&s.ID, &s.UserID, &s.Device, &s.IP, &s.UserAgent, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &s.RevokedAt, &s.ImpersonatorID)
+	return s, err
+}
+
+func (r *SessionRepository) CreateSession(ctx context.Context, s *models.Session) error {
+	query := `
+		INSERT INTO sessions (user_id, device, ip, user_agent, expires_at, impersonator_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, last_seen_at
+	`
+	err := r.pool.QueryRow(ctx, query, s.UserID, s.Device, s.IP, s.UserAgent, s.ExpiresAt, s.ImpersonatorID).
+		Scan(&s.ID, &s.CreatedAt, &s.LastSeenAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+func (r *SessionRepository) GetActiveSessionsByUserID(ctx context.Context, userID uuid.UUID) ([]models.Session, error) {
+	query := `
+		SELECT ` + sessionColumns + ` FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_seen_at DESC
+	`
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// GetSessionUser returns the role and email verification of the session's
+// user, or "session not found" when the session does not belong to the user
+// or is no longer active.
+func (r *SessionRepository) GetSessionUser(ctx context.Context, sessionID, userID uuid.UUID) (*models.User, error) {
+	query := `
+		SELECT u.id, u.role, u.email_verified_at FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.id = $1 AND s.user_id = $2 AND s.revoked_at IS NULL AND s.expires_at > NOW()
+	`
+	var user models.User
+	if err := r.pool.QueryRow(ctx, query, sessionID, userID).Scan(&user.ID, &user.Role, &user.EmailVerifiedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, errors.New("session not found")
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return &user, nil
+}
+
+// TouchSession records activity on the session and extends its lifetime.
+func (r *SessionRepository) TouchSession(ctx context.Context, id uuid.UUID, expiresAt time.Time) error {
+	_, err := r.pool.Exec(ctx, `UPDATE sessions SET last_seen_at = NOW(), expires_at = $1 WHERE id = $2`, expiresAt, id)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
+// RevokeSession revokes one of the user's sessions together with its refresh
+// tokens.
+func (r *SessionRepository) RevokeSession(ctx context.Context, id, userID uuid.UUID) error {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	commandTag, err := tx.Exec(ctx,
+		`UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, id, userID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errors.New("session not found")
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE refresh_tokens SET revoked_at = NOW() WHERE family_id = $1 AND revoked_at IS NULL`, id,
+	); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -22,12 +22,12 @@ func NewUserRepository(pool *pgxpool.Pool) *UserRepository {
 
 func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (username, email, password) 
-		VALUES ($1, $2, $3) 
-		RETURNING id, created_at, updated_at, is_admin, profile_picture
+		INSERT INTO users (username, email, password, role) 
+		VALUES ($1, $2, $3, $4) 
+		RETURNING id, created_at, updated_at, profile_picture
 	`
-	err := r.pool.QueryRow(ctx, query, user.Username, user.Email, user.Password).
-		Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt, &user.IsAdmin, &user.ProfilePicture)
+	err := r.pool.QueryRow(ctx, query, user.Username, user.Email, user.Password, user.Role).
+		Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt, &user.ProfilePicture)
 	if err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
 	}
@@ -35,9 +35,9 @@ func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) erro
 }
 
 func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
-	query := `SELECT id, username, email, password, is_admin, profile_picture, password_reset_token, password_reset_expires FROM users WHERE email = $1`
+	query := `SELECT id, username, email, email_verified_at, password, role, profile_picture, password_reset_token_hash, password_reset_expires, failed_login_attempts, last_failed_login_at, locked_until FROM users WHERE email = $1`
 	var user models.User
-	err := r.pool.QueryRow(ctx, query, email).Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.IsAdmin, &user.ProfilePicture, &user.PasswordResetToken, &user.PasswordResetExpires)
+	err := r.pool.QueryRow(ctx, query, email).Scan(&user.ID, &user.Username, &user.Email, &user.EmailVerifiedAt, &user.Password, &user.Role, &user.ProfilePicture, &user.PasswordResetTokenHash, &user.PasswordResetExpires, &user.FailedLoginAttempts, &user.LastFailedLoginAt, &user.LockedUntil)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, errors.New("user not found")
@@ -48,9 +48,9 @@ func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*mod
 }
 
 func (r *UserRepository) GetUserById(ctx context.Context, id uuid.UUID) (*models.User, error) {
-	query := `SELECT id, username, email, password, is_admin, profile_picture, password_reset_token, password_reset_expires FROM users WHERE id = $1`
+	query := `SELECT id, username, email, email_verified_at, password, role, profile_picture, password_reset_token_hash, password_reset_expires, failed_login_attempts, last_failed_login_at, locked_until FROM users WHERE id = $1`
 	var user models.User
-	err := r.pool.QueryRow(ctx, query, id).Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.IsAdmin, &user.ProfilePicture, &user.PasswordResetToken, &user.PasswordResetExpires)
+	err := r.pool.QueryRow(ctx, query, id).Scan(&user.ID, &user.Username, &user.Email, &user.EmailVerifiedAt, &user.Password, &user.Role, &user.ProfilePicture, &user.PasswordResetTokenHash, &user.PasswordResetExpires, &user.FailedLoginAttempts, &user.LastFailedLoginAt, &user.LockedUntil)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, errors.New("user not found")
@@ -60,14 +60,16 @@ func (r *UserRepository) GetUserById(ctx context.Context, id uuid.UUID) (*models
 	return &user, nil
 }
 
+// UpdateUser saves the profile. Changing the email clears its verification.
 func (r *UserRepository) UpdateUser(ctx context.Context, user *models.User) error {
 	query := `
 		UPDATE users 
-		SET username = $1, email = $2, is_admin = $3, profile_picture = $4, updated_at = NOW()
+		SET username = $1, email = $2, role = $3, profile_picture = $4, updated_at = NOW(),
+			email_verified_at = CASE WHEN email = $2 THEN email_verified_at END
 		WHERE id = $5
-		RETURNING updated_at
+		RETURNING updated_at, email_verified_at
 	`
-	err := r.pool.QueryRow(ctx, query, user.Username, user.Email, user.IsAdmin, user.ProfilePicture, user.ID).Scan(&user.UpdatedAt)
+	err := r.pool.QueryRow(ctx, query, user.Username, user.Email, user.Role, user.ProfilePicture, user.ID).Scan(&user.UpdatedAt, &user.EmailVerifiedAt)
 	if err != nil {
 		return fmt.Errorf("failed to update user: %w", err)
 	}
@@ -75,7 +77,7 @@ func (r *UserRepository) UpdateUser(ctx context.Context, user *models.User) erro
 }
 
 func (r *UserRepository) GetAllUsers(ctx context.Context) ([]models.User, error) {
-	query := `SELECT id, username, email, is_admin, profile_picture, created_at, updated_at FROM users`
+	query := `SELECT id, username, email, email_verified_at, role, profile_picture, created_at, updated_at FROM users`
 	rows, err := r.pool.Query(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query users: %w", err)
@@ -85,7 +87,7 @@ func (r *UserRepository) GetAllUsers(ctx context.Context) ([]models.User, error)
 	var users []models.User
 	for rows.Next() {
 		var user models.User
-		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.IsAdmin, &user.ProfilePicture, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.EmailVerifiedAt, &user.Role, &user.ProfilePicture, &user.CreatedAt, &user.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan user: %w", err)
 		}
 		users = append(users, user)
@@ -118,21 +120,231 @@ func (r *UserRepository) DeleteUser(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-func (r *UserRepository) UpdatePasswordResetToken(ctx context.Context, userID uuid.UUID, token string, expiresAt time.Time) error {
-	query := `UPDATE users SET password_reset_token = $1, password_reset_expires = $2 WHERE id = $3`
-	_, err := r.pool.Exec(ctx, query, token, expiresAt, userID)
+// UpdatePasswordResetToken stores the hash of a new reset token, replacing
+// any previous token and its failed attempts.
+func (r *UserRepository) UpdatePasswordResetToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	query := `UPDATE users SET password_reset_token_hash = $1, password_reset_expires = $2, password_reset_attempts = 0 WHERE id = $3`
+	_, err := r.pool.Exec(ctx, query, tokenHash, expiresAt, userID)
 	if err != nil {
 		return fmt.Errorf("failed to update password reset token: %w", err)
 	}
 	return nil
 }
 
+// UsePasswordResetAttempt counts an attempt against the user's reset token and
+// returns the token hash and expiry to check it against. Once maxAttempts
+// have been used the token can't be checked anymore, so concurrent guesses
+// can't exceed the limit.
+func (r *UserRepository) UsePasswordResetAttempt(ctx context.Context, userID uuid.UUID, maxAttempts int) (string, time.Time, error) {
+	query := `
+		UPDATE users SET password_reset_attempts = password_reset_attempts + 1
+		WHERE id = $1 AND password_reset_token_hash IS NOT NULL AND password_reset_attempts < $2
+		RETURNING password_reset_token_hash, password_reset_expires
+	`
+	var tokenHash string
+	var expiresAt time.Time
+	if err := r.pool.QueryRow(ctx, query, userID, maxAttempts).Scan(&tokenHash, &expiresAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", time.Time{}, errors.New("invalid token")
+		}
+		return "", time.Time{}, fmt.Errorf("failed to check password reset token: %w", err)
+	}
+	return tokenHash, expiresAt, nil
+}
+
+// UpdatePassword sets a new password and revokes all of the user's sessions,
+// so that tokens issued before the change stop working. It also lifts any
+// lockout, since whoever set the password has proven control of the account.
 func (r *UserRepository) UpdatePassword(ctx context.Context, userID uuid.UUID, password string) error {
-	// Also clear the reset token
-	query := `UPDATE users SET password = $1, password_reset_token = NULL, password_reset_expires = NULL, updated_at = NOW() WHERE id = $2`
-	_, err := r.pool.Exec(ctx, query, password, userID)
+	tx, err := r.pool.Begin(ctx)
 	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Also clear the reset token and failed logins
+	query := `
+		UPDATE users SET password = $1, password_reset_token_hash = NULL, password_reset_expires = NULL,
+			password_reset_attempts = 0, failed_login_attempts = 0, locked_until = NULL,
+			account_unlock_token_hash = NULL, account_unlock_expires = NULL, updated_at = NOW()
+		WHERE id = $2
+	`
+	if _, err := tx.Exec(ctx, query, password, userID); err != nil {
 		return fmt.Errorf("failed to update password: %w", err)
 	}
+
+	if _, err := tx.Exec(ctx, `UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `UPDATE refresh_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID); err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RehashPassword replaces the stored hash of an unchanged password with a new
+// hash of it. Sessions stay valid, and nothing happens if the password was
+// changed since oldHash was read.
+func (r *UserRepository) RehashPassword(ctx context.Context, userID uuid.UUID, oldHash, newHash string) error {
+	_, err := r.pool.Exec(ctx, `UPDATE users SET password = $3 WHERE id = $1 AND password = $2`, userID, oldHash, newHash)
+	if err != nil {
+		return fmt.Errorf("failed to rehash password: %w", err)
+	}
+	return nil
+}
+
+// loginAttemptSet and loginAttemptAllowed count a login attempt and decide
+// whether it may go ahead, for rows aliased "a" with the failed login columns
+// of users. $2 is the number of free attempts, $3 the longest delay and $4
+// the window after which failures are forgotten.
+const (
+	loginAttemptSet = `
+		failed_login_attempts = CASE
+			WHEN a.locked_until <= NOW() OR a.last_failed_login_at < NOW() - $4::interval THEN 1
+			ELSE a.failed_login_attempts + 1 END,
+		last_failed_login_at = NOW(),
+		locked_until = CASE WHEN a.locked_until <= NOW() THEN NULL ELSE a.locked_until END`
+	loginAttemptAllowed = `
+		(a.locked_until IS NULL OR a.locked_until <= NOW())
+		AND (a.last_failed_login_at IS NULL OR a.last_failed_login_at < NOW() - $4::interval
+			OR a.locked_until <= NOW() OR a.failed_login_attempts < $2
+			OR a.last_failed_login_at + LEAST(make_interval(secs => power(2, LEAST(a.failed_login_attempts - $2, 16))), $3::interval) <= NOW())`
+)
+
+// BeginLoginAttempt claims a password check for the user, counting it as a
+// failed login until UnlockAccount clears the count. The check and the count
+// are one statement, so concurrent attempts can't all pass the delay. After
+// freeAttempts failures an attempt must wait 1s, 2s, 4s... up to maxDelay
+// since the previous one. An expired lockout, or a last failure older than
+// window, starts the count over. It reports false while the account is
+// locked or has to wait.
+func (r *UserRepository) BeginLoginAttempt(ctx context.Context, userID uuid.UUID, freeAttempts int, maxDelay, window time.Duration) (bool, error) {
+	query := `UPDATE users AS a SET` + loginAttemptSet + `
+		WHERE a.id = $1 AND` + loginAttemptAllowed + `
+		RETURNING a.id`
+	var id uuid.UUID
+	if err := r.pool.QueryRow(ctx, query, userID, freeAttempts, maxDelay, window).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to begin login attempt: %w", err)
+	}
+	return true, nil
+}
+
+// BeginUnknownLoginAttempt is BeginLoginAttempt for an email without an
+// account, so that logins to it are delayed and locked out like any other.
+func (r *UserRepository) BeginUnknownLoginAttempt(ctx context.Context, email string, freeAttempts int, maxDelay, window time.Duration) (bool, error) {
+	query := `
+		INSERT INTO unknown_login_attempts AS a (email, failed_login_attempts, last_failed_login_at)
+		VALUES ($1, 1, NOW())
+		ON CONFLICT (email) DO UPDATE SET` + loginAttemptSet + `
+		WHERE` + loginAttemptAllowed + `
+		RETURNING a.email`
+	var key string
+	if err := r.pool.QueryRow(ctx, query, email, freeAttempts, maxDelay, window).Scan(&key); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to begin login attempt: %w", err)
+	}
+	return true, nil
+}
+
+// GetUnknownLoginAttempts returns the failed logins of an email without an
+// account, as a user with just the failed login fields set.
+func (r *UserRepository) GetUnknownLoginAttempts(ctx context.Context, email string) (*models.User, error) {
+	query := `
+		SELECT failed_login_attempts, last_failed_login_at, locked_until
+		FROM unknown_login_attempts WHERE email = $1
+	`
+	var user models.User
+	err := r.pool.QueryRow(ctx, query, email).Scan(&user.FailedLoginAttempts, &user.LastFailedLoginAt, &user.LockedUntil)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to get login attempts: %w", err)
+	}
+	return &user, nil
+}
+
+// LockUnknownLoginAfterFailures is LockAfterFailedLogins for an email without
+// an account.
+func (r *UserRepository) LockUnknownLoginAfterFailures(ctx context.Context, email string, threshold int, lockout time.Duration) error {
+	query := `
+		UPDATE unknown_login_attempts SET locked_until = NOW() + $3::interval
+		WHERE email = $1 AND failed_login_attempts >= $2 AND (locked_until IS NULL OR locked_until <= NOW())
+	`
+	if _, err := r.pool.Exec(ctx, query, email, threshold, lockout); err != nil {
+		return fmt.Errorf("failed to lock login: %w", err)
+	}
+	return nil
+}
+
+// LockAfterFailedLogins locks the account for lockout once threshold failed
+// logins are counted, unless it is locked already. It returns until when, or
+// nil if this call didn't lock the account.
+func (r *UserRepository) LockAfterFailedLogins(ctx context.Context, userID uuid.UUID, threshold int, lockout time.Duration) (*time.Time, error) {
+	query := `
+		UPDATE users SET locked_until = NOW() + $3::interval
+		WHERE id = $1 AND failed_login_attempts >= $2 AND (locked_until IS NULL OR locked_until <= NOW())
+		RETURNING locked_until
+	`
+	var lockedUntil time.Time
+	if err := r.pool.QueryRow(ctx, query, userID, threshold, lockout).Scan(&lockedUntil); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to lock account: %w", err)
+	}
+	return &lockedUntil, nil
+}
+
+// UpdateAccountUnlockToken stores the hash of the token mailed to a locked
+// out user, replacing any previous one.
+func (r *UserRepository) UpdateAccountUnlockToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	query := `UPDATE users SET account_unlock_token_hash = $1, account_unlock_expires = $2 WHERE id = $3`
+	if _, err := r.pool.Exec(ctx, query, tokenHash, expiresAt, userID); err != nil {
+		return fmt.Errorf("failed to update account unlock token: %w", err)
+	}
+	return nil
+}
+
+// UnlockAccountByToken lifts the lockout of the user holding an unexpired
+// unlock token. The token can only be used once.
+func (r *UserRepository) UnlockAccountByToken(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	query := `
+		UPDATE users SET failed_login_attempts = 0, last_failed_login_at = NULL, locked_until = NULL,
+			account_unlock_token_hash = NULL, account_unlock_expires = NULL
+		WHERE account_unlock_token_hash = $1 AND account_unlock_expires > NOW()
+		RETURNING id
+	`
+	var userID uuid.UUID
+	if err := r.pool.QueryRow(ctx, query, tokenHash).Scan(&userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, errors.New("invalid or expired token")
+		}
+		return uuid.Nil, fmt.Errorf("failed to unlock account: %w", err)
+	}
+	return userID, nil
+}
+
+// UnlockAccount lifts the lockout of the user and forgets their failed
+// logins, as after a successful login.
+func (r *UserRepository) UnlockAccount(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		UPDATE users SET failed_login_attempts = 0, last_failed_login_at = NULL, locked_until = NULL,
+			account_unlock_token_hash = NULL, account_unlock_expires = NULL
+		WHERE id = $1
+	`
+	commandTag, err := r.pool.Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to unlock account: %w", err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return errors.New("user not found")
+	}
 	return nil
 }
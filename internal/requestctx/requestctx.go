@@ -0,0 +1,41 @@
+// Package requestctx carries details of the HTTP request, such as its ID and
+// the authenticated user, in the context handed to services, so that audit
+// events can tell who did what from where.
+package requestctx
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Info describes the request a context belongs to. The auth middleware fills
+// in the user fields once the request is authenticated.
+type Info struct {
+	RequestID string
+	// IP is the client's address, taken from X-Forwarded-For only when a
+	// trusted proxy set it; RemoteIP is the address of the connection.
+	IP        string
+	RemoteIP  string
+	UserAgent string
+
+	UserID         *uuid.UUID
+	ImpersonatorID *uuid.UUID
+	APIKeyID       *uuid.UUID
+	// APIKeyCompanyID limits a request made with a company API key to the
+	// jobs and applications of that company.
+	APIKeyCompanyID *uuid.UUID
+}
+
+type infoKey struct{}
+
+// With returns a context carrying info.
+func With(ctx context.Context, info *Info) context.Context {
+	return context.WithValue(ctx, infoKey{}, info)
+}
+
+// From returns the request info of ctx, or nil outside of a request.
+func From(ctx context.Context) *Info {
+	info, _ := ctx.Value(infoKey{}).(*Info)
+	return info
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"job-portal-api/internal/handlers"
+	"job-portal-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAPIKeyRoutes(r *gin.RouterGroup, handler *handlers.APIKeyHandler) {
+	keys := r.Group("/auth/api-keys")
+	keys.Use(middleware.AuthMiddleware())
+	{
+		keys.GET("/", handler.GetAPIKeys)
+		keys.POST("/", middleware.RejectImpersonation(), handler.CreateAPIKey)
+		keys.DELETE("/:id", middleware.RejectImpersonation(), handler.RevokeAPIKey)
+	}
+}
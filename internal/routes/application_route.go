@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"job-portal-api/internal/handlers"
+	"job-portal-api/internal/middleware"
+	"job-portal-api/internal/policy"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterApplicationRoutes(r *gin.RouterGroup, handler *handlers.ApplicationHandler) {
+	jobs := r.Group("/jobs")
+	jobs.Use(middleware.AuthMiddleware())
+	{
+		jobs.POST("/:id/applications", middleware.RequirePermission(policy.ApplicationsCreate), middleware.RequireVerifiedEmail(), handler.Apply)
+		jobs.GET("/:id/applications", handler.GetApplicationsByJob)
+	}
+
+	applications := r.Group("/applications")
+	applications.Use(middleware.AuthMiddleware())
+	{
+		applications.GET("/me", handler.GetMyApplications)
+		applications.PATCH("/:id/status", handler.UpdateStatus)
+		applications.GET("/:id/history", handler.GetStatusHistory)
+	}
+}
@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"job-portal-api/internal/handlers"
+	"job-portal-api/internal/middleware"
+	"job-portal-api/internal/policy"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAuditRoutes(r *gin.RouterGroup, handler *handlers.AuditHandler) {
+	audit := r.Group("/admin/audit")
+	audit.Use(middleware.AuthMiddleware(), middleware.RequirePermission(policy.AuditRead))
+	{
+		audit.GET("/", handler.GetEvents)
+	}
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"job-portal-api/internal/handlers"
 	"job-portal-api/internal/middleware"
+	"job-portal-api/internal/policy"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,10 +13,23 @@ func RegisterAuthRoutes(r *gin.RouterGroup, handler *handlers.AuthHandler) {
 	{
 		auth.POST("/register", handler.Register)
 		auth.POST("/login", handler.Login)
+		auth.POST("/login/mfa", handler.VerifyMFA)
+		auth.POST("/login/mfa/enroll", handler.StartMFAEnrollment)
+		auth.POST("/refresh", handler.Refresh)
+		auth.POST("/logout", handler.Logout)
+		auth.POST("/verify-email", handler.VerifyEmail)
+		auth.POST("/resend-verification", middleware.AuthMiddleware(), handler.ResendVerification)
 		auth.POST("/forgot-password", handler.ForgotPassword)
 		auth.POST("/reset-password", handler.ResetPassword)
-		auth.POST("/change-password", middleware.AuthMiddleware(), handler.ChangePassword)
-		// Admin only
-		auth.POST("/users/:id/change-password", middleware.AuthMiddleware(), middleware.AdminMiddleware(), handler.ChangeUserPassword)
+		auth.POST("/unlock-account", handler.UnlockAccount)
+		auth.POST("/change-password", middleware.AuthMiddleware(), middleware.RejectImpersonation(), handler.ChangePassword)
+		auth.POST("/users/:id/change-password", middleware.AuthMiddleware(), middleware.RejectImpersonation(), middleware.RequirePermission(policy.UsersPasswordUpdateAny), handler.ChangeUserPassword)
+		auth.POST("/users/:id/unlock", middleware.AuthMiddleware(), middleware.RequirePermission(policy.UsersUnlockAny), handler.UnlockUser)
 	}
 }
+
+// RegisterWellKnownRoutes registers the discovery endpoints served from the
+// root of the site rather than under /api.
+func RegisterWellKnownRoutes(r *gin.RouterGroup, handler *handlers.AuthHandler) {
+	r.GET("/.well-known/jwks.json", handler.JWKS)
+}
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"job-portal-api/internal/handlers"
+	"job-portal-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterCompanyRoutes(r *gin.RouterGroup, handler *handlers.CompanyHandler) {
+	companies := r.Group("/companies")
+	companies.Use(middleware.AuthMiddleware())
+	{
+		companies.POST("/", handler.CreateCompany)
+		companies.GET("/", handler.GetAllCompanies)
+		companies.GET("/me", handler.GetMyCompanies)
+		companies.GET("/:id", handler.GetCompanyByID)
+		companies.PUT("/:id", handler.UpdateCompany)
+		companies.DELETE("/:id", handler.DeleteCompany)
+		companies.GET("/:id/members", handler.GetMembers)
+		companies.POST("/:id/members", handler.AddMember)
+		companies.PUT("/:id/members/:userId", handler.UpdateMemberRole)
+		companies.DELETE("/:id/members/:userId", handler.RemoveMember)
+		companies.POST("/:id/invitations", handler.CreateInvitation)
+		companies.GET("/:id/invitations", handler.GetPendingInvitations)
+		companies.DELETE("/:id/invitations/:invitationId", handler.RevokeInvitation)
+	}
+
+	invitations := r.Group("/invitations")
+	invitations.Use(middleware.AuthMiddleware())
+	{
+		invitations.POST("/accept", handler.AcceptInvitation)
+	}
+}
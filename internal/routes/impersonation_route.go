@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"job-portal-api/internal/handlers"
+	"job-portal-api/internal/middleware"
+	"job-portal-api/internal/policy"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterImpersonationRoutes(r *gin.RouterGroup, handler *handlers.ImpersonationHandler) {
+	admin := r.Group("/admin")
+	admin.Use(middleware.AuthMiddleware(), middleware.RejectImpersonation())
+	{
+		admin.POST("/users/:id/impersonate", middleware.RequirePermission(policy.UsersImpersonate), handler.Impersonate)
+	}
+}
@@ -11,11 +11,16 @@ func RegisterJobRoutes(r *gin.RouterGroup, handler *handlers.JobHandler) {
 	jobs := r.Group("/jobs")
 	jobs.Use(middleware.AuthMiddleware())
 	{
-		jobs.POST("/", handler.CreateJob)
+		jobs.POST("/", middleware.RequireVerifiedEmail(), handler.CreateJob)
 		jobs.GET("/", handler.GetAllJobs)
 		jobs.GET("/me", handler.GetJobsByUser)
+		jobs.GET("/search", handler.SearchJobs)
+		jobs.GET("/suggest", handler.SuggestJobValues)
 		jobs.GET("/:id", handler.GetJobByID)
 		jobs.PUT("/:id", handler.UpdateJob)
 		jobs.DELETE("/:id", handler.DeleteJob)
+		jobs.POST("/:id/publish", handler.PublishJob)
+		jobs.POST("/:id/pause", handler.PauseJob)
+		jobs.POST("/:id/close", handler.CloseJob)
 	}
 }
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"job-portal-api/internal/handlers"
+	"job-portal-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterMFARoutes(r *gin.RouterGroup, handler *handlers.MFAHandler) {
+	mfa := r.Group("/auth/mfa")
+	mfa.Use(middleware.AuthMiddleware(), middleware.RejectImpersonation())
+	{
+		mfa.GET("/", handler.GetStatus)
+		mfa.DELETE("/", handler.Disable)
+		mfa.POST("/enroll", handler.StartEnrollment)
+		mfa.POST("/confirm", handler.ConfirmEnrollment)
+		mfa.POST("/recovery-codes", handler.RegenerateRecoveryCodes)
+	}
+}
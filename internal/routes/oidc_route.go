@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"job-portal-api/internal/handlers"
+	"job-portal-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterOIDCRoutes(r *gin.RouterGroup, handler *handlers.OIDCHandler) {
+	oidc := r.Group("/auth/oidc")
+	{
+		oidc.GET("/providers", handler.GetProviders)
+		oidc.GET("/:provider/authorize", handler.Authorize)
+		oidc.GET("/:provider/callback", handler.Callback)
+		oidc.POST("/:provider/link", middleware.AuthMiddleware(), middleware.RejectImpersonation(), handler.Link)
+	}
+
+	identities := r.Group("/auth/identities")
+	identities.Use(middleware.AuthMiddleware())
+	{
+		identities.GET("/", handler.GetIdentities)
+		identities.DELETE("/:id", middleware.RejectImpersonation(), handler.UnlinkIdentity)
+	}
+}
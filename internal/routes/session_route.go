@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"job-portal-api/internal/handlers"
+	"job-portal-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterSessionRoutes(r *gin.RouterGroup, handler *handlers.SessionHandler) {
+	sessions := r.Group("/auth/sessions")
+	sessions.Use(middleware.AuthMiddleware())
+	{
+		sessions.GET("/", handler.GetSessions)
+		sessions.DELETE("/:id", middleware.RejectImpersonation(), handler.RevokeSession)
+	}
+}
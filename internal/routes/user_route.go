@@ -3,6 +3,7 @@ package routes
 import (
 	"job-portal-api/internal/handlers"
 	"job-portal-api/internal/middleware"
+	"job-portal-api/internal/policy"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,9 +13,9 @@ func RegisterUserRoutes(r *gin.RouterGroup, handler *handlers.UserHandler) {
 	user.Use(middleware.AuthMiddleware())
 	{
 		user.GET("/:id", handler.GetUserById)
-		user.GET("/", handler.GetAllUsers)
-		user.PUT("/:id", handler.UpdateUser)
-		user.DELETE("/:id", handler.DeleteUser)
+		user.GET("/", middleware.RequirePermission(policy.UsersReadAny), handler.GetAllUsers)
+		user.PUT("/:id", middleware.RejectImpersonation(), handler.UpdateUser)
+		user.DELETE("/:id", middleware.RejectImpersonation(), handler.DeleteUser)
 		user.POST("/:id/upload-picture", handler.UploadProfilePicture)
 	}
 }
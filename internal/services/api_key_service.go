@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/policy"
+	"job-portal-api/internal/repository"
+	"job-portal-api/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// API keys look like "jpk_<prefix>_<secret>". The part up to the prefix is
+// stored in plaintext so users can tell their keys apart.
+const (
+	apiKeyTag          = "jpk_"
+	apiKeyPrefixLength = 8
+	apiKeyAlphabet     = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+// companyKeyScopes are the scopes a company key can have; it acts for the
+// company, not for its creator's account.
+var companyKeyScopes = map[models.APIKeyScope]bool{
+	models.ScopeJobsRead:          true,
+	models.ScopeJobsWrite:         true,
+	models.ScopeApplicationsRead:  true,
+	models.ScopeApplicationsWrite: true,
+	models.ScopeCompaniesRead:     true,
+	models.ScopeCompaniesWrite:    true,
+}
+
+type APIKeyService struct {
+	repo        *repository.APIKeyRepository
+	companyRepo *repository.CompanyRepository
+	audit       *AuditService
+}
+
+func NewAPIKeyService(repo *repository.APIKeyRepository, companyRepo *repository.CompanyRepository, audit *AuditService) *APIKeyService {
+	return &APIKeyService{repo: repo, companyRepo: companyRepo, audit: audit}
+}
+
+// CreateAPIKey issues a key for the request user, or for a company the
+// request user owns when key.CompanyID is set. The key itself is returned
+// only here; afterwards only its prefix is known.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, key *models.APIKey, requestUser *models.User) (string, error) {
+	key.Name = strings.TrimSpace(key.Name)
+	if key.Name == "" {
+		return "", errors.New("name is required")
+	}
+	if len(key.Scopes) == 0 {
+		return "", errors.New("at least one scope is required")
+	}
+	for _, scope := range key.Scopes {
+		if !scope.IsValid() {
+			return "", errors.New("invalid scope")
+		}
+		if key.CompanyID != nil && !companyKeyScopes[scope] {
+			return "", errors.New("invalid scope for a company key")
+		}
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()) {
+		return "", errors.New("expiry must be in the future")
+	}
+
+	// A company key acts through its creator's membership, so only actual
+	// owners can create one
+	if key.CompanyID != nil {
+		role, err := s.companyRepo.GetMemberRole(ctx, *key.CompanyID, requestUser.ID)
+		if err != nil {
+			return "", err
+		}
+		if !role.AtLeast(models.CompanyRoleOwner) {
+			return "", errors.New("unauthorized to manage company api keys")
+		}
+	}
+
+	prefix, err := utils.GenerateRandomString(apiKeyPrefixLength, apiKeyAlphabet)
+	if err != nil {
+		return "", err
+	}
+	secret, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	key.UserID = requestUser.ID
+	key.Prefix = apiKeyTag + prefix
+	rawKey := key.Prefix + "_" + secret
+	if err := s.repo.CreateAPIKey(ctx, key, utils.HashToken(rawKey)); err != nil {
+		return "", err
+	}
+
+	event := newAuditEvent(models.AuditAPIKeyCreate, models.AuditTargetAPIKey, key.ID)
+	event.Changes = auditDiff(nil, key)
+	s.audit.Record(ctx, event)
+	return rawKey, nil
+}
+
+// GetAPIKeys lists the request user's personal keys, or the keys of a
+// company they own.
+func (s *APIKeyService) GetAPIKeys(ctx context.Context, companyID *uuid.UUID, requestUser *models.User) ([]models.APIKey, error) {
+	if companyID == nil {
+		return s.repo.GetUserAPIKeys(ctx, requestUser.ID)
+	}
+
+	ok, err := s.canManageCompanyKeys(ctx, *companyID, requestUser)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("unauthorized to manage company api keys")
+	}
+	return s.repo.GetCompanyAPIKeys(ctx, *companyID)
+}
+
+// RevokeAPIKey revokes one of the request user's keys. Company keys can also
+// be revoked by any owner of the company.
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, id uuid.UUID, requestUser *models.User) error {
+	key, err := s.repo.GetAPIKeyByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if key.UserID != requestUser.ID {
+		if key.CompanyID == nil {
+			return errors.New("api key not found")
+		}
+		ok, err := s.canManageCompanyKeys(ctx, *key.CompanyID, requestUser)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("api key not found")
+		}
+	}
+
+	if err := s.repo.RevokeAPIKey(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, newAuditEvent(models.AuditAPIKeyRevoke, models.AuditTargetAPIKey, id))
+	return nil
+}
+
+// ValidateAPIKey returns the key and the current role and email verification
+// of the user it acts as, or "invalid api key".
+func (s *APIKeyService) ValidateAPIKey(ctx context.Context, rawKey string) (*models.APIKey, *models.User, error) {
+	if !strings.HasPrefix(rawKey, apiKeyTag) {
+		return nil, nil, errors.New("invalid api key")
+	}
+
+	key, user, err := s.repo.GetActiveAPIKeyByHash(ctx, utils.HashToken(rawKey))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.repo.TouchAPIKey(ctx, key.ID); err != nil {
+		log.Printf("Failed to record use of api key %s: %v", key.ID, err)
+	}
+	return key, user, nil
+}
+
+func (s *APIKeyService) canManageCompanyKeys(ctx context.Context, companyID uuid.UUID, requestUser *models.User) (bool, error) {
+	if policy.Can(requestUser, policy.CompaniesManageAny) {
+		return true, nil
+	}
+	role, err := s.companyRepo.GetMemberRole(ctx, companyID, requestUser.ID)
+	if err != nil {
+		return false, err
+	}
+	return role.AtLeast(models.CompanyRoleOwner), nil
+}
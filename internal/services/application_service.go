@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mime/multipart"
+
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/policy"
+	"job-portal-api/internal/repository"
+	"job-portal-api/pkg/cloudinary"
+	"job-portal-api/pkg/mailer"
+
+	"github.com/google/uuid"
+)
+
+// applicationTransitions lists the statuses each status may move to.
+// Hired, rejected and withdrawn are terminal.
+var applicationTransitions = map[models.ApplicationStatus][]models.ApplicationStatus{
+	models.ApplicationStatusApplied:   {models.ApplicationStatusScreening, models.ApplicationStatusRejected, models.ApplicationStatusWithdrawn},
+	models.ApplicationStatusScreening: {models.ApplicationStatusInterview, models.ApplicationStatusRejected, models.ApplicationStatusWithdrawn},
+	models.ApplicationStatusInterview: {models.ApplicationStatusOffer, models.ApplicationStatusRejected, models.ApplicationStatusWithdrawn},
+	models.ApplicationStatusOffer:     {models.ApplicationStatusHired, models.ApplicationStatusRejected, models.ApplicationStatusWithdrawn},
+}
+
+func canTransition(from, to models.ApplicationStatus) bool {
+	for _, next := range applicationTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// canWithdraw reports whether requestUser may withdraw app: only the candidate
+// who applied may, and not through a company API key.
+func canWithdraw(ctx context.Context, app *models.Application, requestUser *models.User) bool {
+	return app.UserID == requestUser.ID && apiKeyCompanyID(ctx) == nil
+}
+
+type ApplicationService struct {
+	repo        *repository.ApplicationRepository
+	jobRepo     *repository.JobRepository
+	companyRepo *repository.CompanyRepository
+	userRepo    *repository.UserRepository
+	cldService  *cloudinary.Service
+	mailer      mailer.Mailer
+}
+
+func NewApplicationService(repo *repository.ApplicationRepository, jobRepo *repository.JobRepository, companyRepo *repository.CompanyRepository, userRepo *repository.UserRepository, cldService *cloudinary.Service, m mailer.Mailer) *ApplicationService {
+	return &ApplicationService{
+		repo:        repo,
+		jobRepo:     jobRepo,
+		companyRepo: companyRepo,
+		userRepo:    userRepo,
+		cldService:  cldService,
+		mailer:      m,
+	}
+}
+
+// Apply submits an application with an optional résumé. Résumés are stored
+// under a name of their own, never the client's filename, so that uploads
+// can't replace one another.
+// Apply submits an application. A résumé, if given, must already have been
+// checked by the caller; ext is its file extension, such as ".pdf".
+func (s *ApplicationService) Apply(ctx context.Context, app *models.Application, file multipart.File, ext string) (*models.Application, error) {
+	if apiKeyCompanyID(ctx) != nil {
+		return nil, errors.New("company api keys can't apply to jobs")
+	}
+
+	job, err := s.jobRepo.GetJobByID(ctx, app.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.UserID == app.UserID {
+		return nil, errors.New("you cannot apply to your own job")
+	}
+
+	if !job.IsOpen() {
+		return nil, errors.New("this job is not accepting applications")
+	}
+
+	// Check before uploading; the insert still catches concurrent attempts
+	applied, err := s.repo.HasApplied(ctx, app.JobID, app.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if applied {
+		return nil, errors.New("you have already applied to this job")
+	}
+
+	if file != nil {
+		fileUrl, publicID, err := s.cldService.UploadRaw(ctx, file, "resumes/"+uuid.NewString()+ext)
+		if err != nil {
+			return nil, err
+		}
+		app.Resume = models.FileUpload{URL: fileUrl, PublicID: publicID}
+	}
+
+	if err := s.repo.CreateApplication(ctx, app); err != nil {
+		// Don't leave an orphaned resume behind when the insert is rejected
+		if app.Resume.PublicID != "" {
+			_ = s.cldService.DeleteRaw(ctx, app.Resume.PublicID)
+		}
+		return nil, err
+	}
+
+	s.notifyApplicationReceived(ctx, job, app)
+	return app, nil
+}
+
+func (s *ApplicationService) GetApplicationsByJob(ctx context.Context, jobID uuid.UUID, requestUser *models.User) ([]models.Application, error) {
+	job, err := s.jobRepo.GetJobByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := canAccessJob(ctx, s.companyRepo, job, requestUser, models.CompanyRoleViewer, policy.ApplicationsReadAny)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("unauthorized to view applications for this job")
+	}
+
+	return s.repo.GetApplicationsByJobID(ctx, jobID)
+}
+
+func (s *ApplicationService) GetApplicationsByUser(ctx context.Context, userID uuid.UUID) ([]models.Application, error) {
+	if apiKeyCompanyID(ctx) != nil {
+		return nil, errors.New("company api keys can't list applications of their creator")
+	}
+	return s.repo.GetApplicationsByUserID(ctx, userID)
+}
+
+func (s *ApplicationService) UpdateStatus(ctx context.Context, applicationID uuid.UUID, status models.ApplicationStatus, note string, requestUser *models.User) (*models.Application, error) {
+	app, err := s.repo.GetApplicationByID(ctx, applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := s.jobRepo.GetJobByID(ctx, app.JobID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Candidates may only withdraw their own application; every other
+	// transition is driven by whoever manages the job.
+	if status == models.ApplicationStatusWithdrawn {
+		if !canWithdraw(ctx, app, requestUser) {
+			return nil, errors.New("unauthorized to update this application")
+		}
+	} else {
+		ok, err := canAccessJob(ctx, s.companyRepo, job, requestUser, models.CompanyRoleRecruiter, policy.ApplicationsUpdateAny)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("unauthorized to update this application")
+		}
+	}
+
+	if !canTransition(app.Status, status) {
+		return nil, fmt.Errorf("invalid status transition from %s to %s", app.Status, status)
+	}
+
+	from := app.Status
+	app.Status = status
+	if err := s.repo.UpdateStatus(ctx, app, from, requestUser.ID, note); err != nil {
+		return nil, err
+	}
+
+	// Candidates already know they withdrew
+	if status != models.ApplicationStatusWithdrawn {
+		s.notifyStatusChanged(ctx, job, app)
+	}
+	return app, nil
+}
+
+func (s *ApplicationService) GetStatusHistory(ctx context.Context, applicationID uuid.UUID, requestUser *models.User) ([]models.ApplicationStatusHistory, error) {
+	app, err := s.repo.GetApplicationByID(ctx, applicationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A company key sees the application as its company does
+	if app.UserID != requestUser.ID || apiKeyCompanyID(ctx) != nil {
+		job, err := s.jobRepo.GetJobByID(ctx, app.JobID)
+		if err != nil {
+			return nil, err
+		}
+		ok, err := canAccessJob(ctx, s.companyRepo, job, requestUser, models.CompanyRoleViewer, policy.ApplicationsReadAny)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("unauthorized to view this application")
+		}
+	}
+
+	return s.repo.GetStatusHistory(ctx, applicationID)
+}
+
+// notifyApplicationReceived emails the job's poster about a new application.
+// Notifications are best effort and never fail the request.
+func (s *ApplicationService) notifyApplicationReceived(ctx context.Context, job *models.Job, app *models.Application) {
+	poster, err := s.userRepo.GetUserById(ctx, job.UserID)
+	if err != nil {
+		log.Printf("Failed to notify poster of job %s: %v", job.ID, err)
+		return
+	}
+	candidate, err := s.userRepo.GetUserById(ctx, app.UserID)
+	if err != nil {
+		log.Printf("Failed to notify poster of job %s: %v", job.ID, err)
+		return
+	}
+
+	err = sendTemplate(ctx, s.mailer, mailer.TemplateApplicationReceived, poster.Email, map[string]interface{}{
+		"Username":      poster.Username,
+		"CandidateName": candidate.Username,
+		"JobTitle":      job.Title,
+	})
+	if err != nil {
+		log.Printf("Failed to notify poster of job %s: %v", job.ID, err)
+	}
+}
+
+// notifyStatusChanged emails the candidate the new status of their
+// application.
+func (s *ApplicationService) notifyStatusChanged(ctx context.Context, job *models.Job, app *models.Application) {
+	candidate, err := s.userRepo.GetUserById(ctx, app.UserID)
+	if err != nil {
+		log.Printf("Failed to notify candidate of application %s: %v", app.ID, err)
+		return
+	}
+
+	err = sendTemplate(ctx, s.mailer, mailer.TemplateStatusChanged, candidate.Email, map[string]interface{}{
+		"Username": candidate.Username,
+		"JobTitle": job.Title,
+		"Status":   app.Status,
+	})
+	if err != nil {
+		log.Printf("Failed to notify candidate of application %s: %v", app.ID, err)
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/requestctx"
+
+	"github.com/google/uuid"
+)
+
+var allApplicationStatuses = []models.ApplicationStatus{
+	models.ApplicationStatusApplied,
+	models.ApplicationStatusScreening,
+	models.ApplicationStatusInterview,
+	models.ApplicationStatusOffer,
+	models.ApplicationStatusHired,
+	models.ApplicationStatusRejected,
+	models.ApplicationStatusWithdrawn,
+}
+
+func TestCanTransition(t *testing.T) {
+	type move struct{ from, to models.ApplicationStatus }
+	allowed := map[move]bool{
+		{models.ApplicationStatusApplied, models.ApplicationStatusScreening}:   true,
+		{models.ApplicationStatusScreening, models.ApplicationStatusInterview}: true,
+		{models.ApplicationStatusInterview, models.ApplicationStatusOffer}:     true,
+		{models.ApplicationStatusOffer, models.ApplicationStatusHired}:         true,
+	}
+	// Any open application can be rejected or withdrawn
+	for _, from := range []models.ApplicationStatus{
+		models.ApplicationStatusApplied,
+		models.ApplicationStatusScreening,
+		models.ApplicationStatusInterview,
+		models.ApplicationStatusOffer,
+	} {
+		allowed[move{from, models.ApplicationStatusRejected}] = true
+		allowed[move{from, models.ApplicationStatusWithdrawn}] = true
+	}
+
+	// Every other move is rejected: skipping or going back a stage, staying
+	// put, and leaving hired, rejected or withdrawn
+	for _, from := range allApplicationStatuses {
+		for _, to := range allApplicationStatuses {
+			want := allowed[move{from, to}]
+			if got := canTransition(from, to); got != want {
+				t.Errorf("canTransition(%s, %s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestApplicationTransitionsTerminalStatuses(t *testing.T) {
+	for _, status := range []models.ApplicationStatus{
+		models.ApplicationStatusHired,
+		models.ApplicationStatusRejected,
+		models.ApplicationStatusWithdrawn,
+	} {
+		if next := applicationTransitions[status]; len(next) != 0 {
+			t.Errorf("%s is terminal but may move to %v", status, next)
+		}
+	}
+}
+
+func TestCanTransitionRejectsUnknownStatus(t *testing.T) {
+	if canTransition(models.ApplicationStatusApplied, "archived") {
+		t.Error("moved to an unknown status")
+	}
+	if canTransition("archived", models.ApplicationStatusRejected) {
+		t.Error("moved from an unknown status")
+	}
+}
+
+func TestCanWithdraw(t *testing.T) {
+	candidate := &models.User{ID: uuid.New(), Role: models.RoleCandidate}
+	recruiter := &models.User{ID: uuid.New(), Role: models.RoleEmployer}
+	admin := &models.User{ID: uuid.New(), Role: models.RoleAdmin}
+	app := &models.Application{ID: uuid.New(), UserID: candidate.ID, Status: models.ApplicationStatusInterview}
+
+	companyID := uuid.New()
+	keyCtx := requestctx.With(context.Background(), &requestctx.Info{UserID: &candidate.ID, APIKeyCompanyID: &companyID})
+
+	for _, tt := range []struct {
+		name string
+		ctx  context.Context
+		user *models.User
+		ok   bool
+	}{
+		{"candidate", context.Background(), candidate, true},
+		{"recruiter of the job", context.Background(), recruiter, false},
+		{"admin", context.Background(), admin, false},
+		{"candidate through a company API key", keyCtx, candidate, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := canWithdraw(tt.ctx, app, tt.user); got != tt.ok {
+				t.Errorf("canWithdraw = %v, want %v", got, tt.ok)
+			}
+		})
+	}
+}
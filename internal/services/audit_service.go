@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"reflect"
+
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/repository"
+	"job-portal-api/internal/requestctx"
+
+	"github.com/google/uuid"
+)
+
+// AuditService keeps the append-only log of security and data relevant
+// actions. Services record events after the action succeeded.
+type AuditService struct {
+	repo *repository.AuditRepository
+}
+
+func NewAuditService(repo *repository.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// newAuditEvent starts an event for an action on a target.
+func newAuditEvent(action, targetType string, targetID uuid.UUID) *models.AuditEvent {
+	return &models.AuditEvent{Action: action, TargetType: targetType, TargetID: &targetID}
+}
+
+// newUserAuditEvent starts an event for an action users take on their own
+// account before they are authenticated, such as logging in.
+func newUserAuditEvent(action string, userID uuid.UUID) *models.AuditEvent {
+	event := newAuditEvent(action, models.AuditTargetUser, userID)
+	event.ActorID = &userID
+	return event
+}
+
+// Record adds the event to the log, filling in the actor and request details
+// from ctx; an ActorID already set on the event is kept, for actions such as
+// logins that happen before the request is authenticated. Failures are only
+// logged, since the action itself has already taken place.
+func (s *AuditService) Record(ctx context.Context, event *models.AuditEvent) {
+	if s == nil {
+		return
+	}
+
+	if info := requestctx.From(ctx); info != nil {
+		if event.ActorID == nil {
+			event.ActorID = info.UserID
+		}
+		event.ImpersonatorID = info.ImpersonatorID
+		event.APIKeyID = info.APIKeyID
+		event.IP = info.IP
+		event.RemoteIP = info.RemoteIP
+		event.UserAgent = info.UserAgent
+		event.RequestID = info.RequestID
+	}
+
+	// Keep the record even if the client has gone away in the meantime
+	if err := s.repo.CreateEvent(context.WithoutCancel(ctx), event); err != nil {
+		log.Printf("Failed to record audit event %s on %s %v: %v", event.Action, event.TargetType, event.TargetID, err)
+	}
+}
+
+func (s *AuditService) GetEvents(ctx context.Context, filter models.AuditFilter) (*models.AuditEventList, error) {
+	return s.repo.GetEvents(ctx, filter)
+}
+
+// auditDiff returns the fields whose JSON representation differs between
+// before and after, either of which may be nil. Fields hidden from JSON, such
+// as password hashes, never appear.
+func auditDiff(before, after interface{}) map[string]models.AuditChange {
+	from, to := auditFields(before), auditFields(after)
+
+	changes := make(map[string]models.AuditChange)
+	for name, v := range from {
+		if w, ok := to[name]; !ok || !reflect.DeepEqual(v, w) {
+			changes[name] = models.AuditChange{From: v, To: to[name]}
+		}
+	}
+	for name, w := range to {
+		if _, ok := from[name]; !ok {
+			changes[name] = models.AuditChange{To: w}
+		}
+	}
+	return changes
+}
+
+func auditFields(v interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	if v == nil || reflect.ValueOf(v).IsNil() {
+		return fields
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
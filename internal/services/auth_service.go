@@ -2,36 +2,158 @@ package services
 
 import (
 	"context"
+	"crypto/subtle"
 	"errors"
+	"log"
 	"net/mail"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
+	"job-portal-api/internal/events"
 	"job-portal-api/internal/models"
 	"job-portal-api/internal/repository"
+	"job-portal-api/pkg/mailer"
+	"job-portal-api/pkg/password"
 	"job-portal-api/pkg/utils"
 
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService struct {
-	userRepo *repository.UserRepository
+	userRepo         *repository.UserRepository
+	refreshTokenRepo *repository.RefreshTokenRepository
+	sessionRepo      *repository.SessionRepository
+	verification     *EmailVerificationService
+	mailer           mailer.Mailer
+	limiter          *RateLimiter
+	mfa              *MFAService
+	passwords        *password.Policy
+	hasher           *password.Hasher
+	resetOptions     PasswordResetOptions
+	lockoutOptions   LoginLockoutOptions
+	bus              *events.Bus
+	audit            *AuditService
+
+	dummyHashOnce sync.Once
+	dummyHash     string
 }
 
-func NewAuthService(userRepo *repository.UserRepository) *AuthService {
-	return &AuthService{userRepo: userRepo}
+// PasswordResetOptions configures the tokens sent by ForgotPassword.
+type PasswordResetOptions struct {
+	// LinkTokens sends a long URL-safe token inside a link instead of a
+	// 6 digit code.
+	LinkTokens bool
+	// LinkURL is the page the link opens; the email and token are appended
+	// as query parameters.
+	LinkURL string
+	// CodeKey keys the hashes of 6 digit codes, so that a copy of the
+	// database isn't enough to recover them.
+	CodeKey []byte
 }
 
-func (s *AuthService) Register(ctx context.Context, username, email, password string) (*models.User, error) {
+// LoginLockoutOptions configures the email sent when an account gets locked.
+type LoginLockoutOptions struct {
+	// UnlockURL is the page the unlock link opens, with the token appended as
+	// a query parameter. Without it the email contains just the token.
+	UnlockURL string
+}
+
+// LoginBlockedError is returned when a login is refused before the password
+// is checked, because of too many failed attempts.
+type LoginBlockedError struct {
+	Reason  string // "too many requests" or "account locked"
+	RetryAt time.Time
+}
+
+func (e *LoginBlockedError) Error() string {
+	return e.Reason
+}
+
+func NewAuthService(userRepo *repository.UserRepository, refreshTokenRepo *repository.RefreshTokenRepository, sessionRepo *repository.SessionRepository, verification *EmailVerificationService, m mailer.Mailer, limiter *RateLimiter, mfa *MFAService, passwords *password.Policy, hasher *password.Hasher, resetOptions PasswordResetOptions, lockoutOptions LoginLockoutOptions, bus *events.Bus, audit *AuditService) *AuthService {
+	return &AuthService{
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		sessionRepo:      sessionRepo,
+		verification:     verification,
+		mailer:           m,
+		limiter:          limiter,
+		mfa:              mfa,
+		passwords:        passwords,
+		hasher:           hasher,
+		resetOptions:     resetOptions,
+		lockoutOptions:   lockoutOptions,
+		bus:              bus,
+		audit:            audit,
+	}
+}
+
+// passwordResetTTL is how long a password reset code stays valid.
+const passwordResetTTL = 60 * time.Minute
+
+// Password reset throttling. A token can be tried resetMaxAttempts times;
+// requests are limited per email and per IP within resetThrottleWindow. The
+// token and email limits hold whatever IP a client claims; the IP limits rely
+// on the client IP coming only from trusted proxies (TRUSTED_PROXIES).
+const (
+	resetMaxAttempts       = 5
+	resetThrottleWindow    = time.Hour
+	forgotPasswordPerEmail = 3
+	forgotPasswordPerIP    = 10
+	resetPasswordPerEmail  = 10
+	resetPasswordPerIP     = 20
+)
+
+// refreshTokenTTL is how long a refresh token can be exchanged for a new
+// token pair.
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+// mfaChallengeTTL is how long the second step of a login may take.
+const mfaChallengeTTL = 5 * time.Minute
+
+// Login brute-force protection. After loginFreeAttempts consecutive failures
+// an account must wait between attempts, twice as long after every further
+// failure up to loginMaxDelay; at loginLockoutThreshold failures it is locked
+// for loginLockoutDuration. The count starts over when a lockout expires or
+// after loginFailureWindow without failures. An IP that fails
+// loginFailuresPerIP times within loginIPWindow is blocked for the rest of
+// the window.
+const (
+	loginFreeAttempts     = 3
+	loginMaxDelay         = time.Minute
+	loginLockoutThreshold = 10
+	loginLockoutDuration  = 30 * time.Minute
+	loginFailureWindow    = 24 * time.Hour
+	loginFailuresPerIP    = 50
+	loginIPWindow         = 15 * time.Minute
+	accountUnlockTTL      = 24 * time.Hour
+	unlockAccountPerIP    = 20
+)
+
+// Register creates an account. New users sign up as candidates or employers;
+// other roles can only be granted afterwards.
+func (s *AuthService) Register(ctx context.Context, username, email, password string, role models.Role) (*models.User, error) {
 	if username == "" || email == "" || password == "" {
 		return nil, errors.New("all fields are required")
 	}
 
+	if role == "" {
+		role = models.RoleCandidate
+	}
+	if role != models.RoleCandidate && role != models.RoleEmployer {
+		return nil, errors.New("invalid role")
+	}
+
 	if _, err := mail.ParseAddress(email); err != nil {
 		return nil, errors.New("invalid email format")
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err := s.passwords.Check(password, username, email); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := s.hasher.Hash(password)
 	if err != nil {
 		return nil, err
 	}
@@ -39,74 +161,529 @@ func (s *AuthService) Register(ctx context.Context, username, email, password st
 	user := &models.User{
 		Username: username,
 		Email:    email,
-		Password: string(hashedPassword),
+		Password: hashedPassword,
+		Role:     role,
 	}
 
 	if err := s.userRepo.CreateUser(ctx, user); err != nil {
 		return nil, err
 	}
+	s.audit.Record(ctx, newUserAuditEvent(models.AuditUserRegister, user.ID))
+
+	// The account exists either way; the user can ask for a new email
+	if err := s.verification.SendVerification(ctx, user); err != nil {
+		log.Printf("Failed to send verification email to user %s: %v", user.ID, err)
+	}
 
 	return user, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, email, password string) (string, *models.User, error) {
+// Login checks the credentials and starts a new session. client carries the
+// device, IP and user agent recorded for the session. Users with a second
+// factor, or whose role requires one, get an MFA challenge instead of tokens.
+//
+// Failed attempts slow down and eventually lock the account, and too many
+// failures from one IP block it; both are refused with a *LoginBlockedError.
+func (s *AuthService) Login(ctx context.Context, email, password string, client *models.Session) (*models.LoginResult, error) {
+	ipKey := "login:ip:" + client.IP
+	blocked, err := s.limiter.Exceeded(ctx, ipKey, loginFailuresPerIP, loginIPWindow)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, &LoginBlockedError{Reason: "too many requests", RetryAt: time.Now().Add(loginIPWindow)}
+	}
+
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
-		return "", nil, errors.New("invalid credentials")
+		if err.Error() != "user not found" {
+			return nil, err
+		}
+		return nil, s.failUnknownLogin(ctx, email, password, ipKey)
+	}
+
+	if err := s.beginLoginAttempt(ctx, user); err != nil {
+		return nil, err
+	}
+
+	ok, rehash, err := s.hasher.Verify(password, user.Password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if _, err := s.limiter.Allow(ctx, ipKey, loginFailuresPerIP, loginIPWindow); err != nil {
+			return nil, err
+		}
+		if err := s.recordFailedLogin(ctx, user); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("invalid credentials")
+	}
+
+	// The attempt was counted as a failure up front
+	if err := s.userRepo.UnlockAccount(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	// Upgrade hashes made with an older algorithm or cost while the
+	// plaintext is at hand
+	if rehash {
+		if err := s.rehashPassword(ctx, user, password); err != nil {
+			log.Printf("Failed to rehash password of user %s: %v", user.ID, err)
+		}
+	}
+
+	return s.completeLogin(ctx, user, client)
+}
+
+func (s *AuthService) rehashPassword(ctx context.Context, user *models.User, password string) error {
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		return err
+	}
+	return s.userRepo.RehashPassword(ctx, user.ID, user.Password, hashedPassword)
+}
+
+// beginLoginAttempt counts the attempt against the account before the
+// password is checked, or refuses it while the account is locked or has to
+// wait after its last failed attempt.
+func (s *AuthService) beginLoginAttempt(ctx context.Context, user *models.User) error {
+	ok, err := s.userRepo.BeginLoginAttempt(ctx, user.ID, loginFreeAttempts, loginMaxDelay, loginFailureWindow)
+	if err != nil || ok {
+		return err
+	}
+
+	// Tell the client how long to wait, from the state that refused it
+	current, err := s.userRepo.GetUserById(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	return loginRefused(current)
+}
+
+// failUnknownLogin refuses a login to an email without an account. It takes
+// the same steps as a wrong password for a real account, including the
+// password hashing, delays and lockout, so that neither the response nor the
+// time it takes tells whether the email is registered.
+func (s *AuthService) failUnknownLogin(ctx context.Context, email, password, ipKey string) error {
+	key := strings.ToLower(email)
+	ok, err := s.userRepo.BeginUnknownLoginAttempt(ctx, key, loginFreeAttempts, loginMaxDelay, loginFailureWindow)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		current, err := s.userRepo.GetUnknownLoginAttempts(ctx, key)
+		if err != nil {
+			return err
+		}
+		return loginRefused(current)
+	}
+
+	_, _, _ = s.hasher.Verify(password, s.dummyPasswordHash())
+
+	if _, err := s.limiter.Allow(ctx, ipKey, loginFailuresPerIP, loginIPWindow); err != nil {
+		return err
+	}
+	if err := s.userRepo.LockUnknownLoginAfterFailures(ctx, key, loginLockoutThreshold, loginLockoutDuration); err != nil {
+		return err
+	}
+	return errors.New("invalid credentials")
+}
+
+// dummyPasswordHash returns a hash made with the current parameters, to
+// verify passwords against when there is no account.
+func (s *AuthService) dummyPasswordHash() string {
+	s.dummyHashOnce.Do(func() {
+		hash, err := s.hasher.Hash("no account has this password")
+		if err != nil {
+			log.Printf("Failed to create dummy password hash: %v", err)
+			return
+		}
+		s.dummyHash = hash
+	})
+	return s.dummyHash
+}
+
+// loginRefused returns the error for a login refused by BeginLoginAttempt,
+// telling the client when to retry from the state that refused it.
+func loginRefused(current *models.User) error {
+	if err := checkLoginAllowed(current, time.Now()); err != nil {
+		return err
+	}
+	return &LoginBlockedError{Reason: "too many requests", RetryAt: time.Now().Add(time.Second)}
+}
+
+// checkLoginAllowed mirrors the conditions of BeginLoginAttempt to tell
+// when a refused login may be retried.
+func checkLoginAllowed(user *models.User, now time.Time) error {
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		return &LoginBlockedError{Reason: "account locked", RetryAt: *user.LockedUntil}
+	}
+
+	if user.FailedLoginAttempts < loginFreeAttempts || user.LastFailedLoginAt == nil {
+		return nil
+	}
+	delay := loginMaxDelay
+	if n := user.FailedLoginAttempts - loginFreeAttempts; n < 16 {
+		delay = min(time.Second<<n, loginMaxDelay)
+	}
+	if retryAt := user.LastFailedLoginAt.Add(delay); now.Before(retryAt) {
+		return &LoginBlockedError{Reason: "too many requests", RetryAt: retryAt}
+	}
+	return nil
+}
+
+// recordFailedLogin locks the account once its failed passwords reach the
+// threshold and mails the user a token to unlock it early. The failure
+// itself was counted by beginLoginAttempt.
+func (s *AuthService) recordFailedLogin(ctx context.Context, user *models.User) error {
+	lockedUntil, err := s.userRepo.LockAfterFailedLogins(ctx, user.ID, loginLockoutThreshold, loginLockoutDuration)
+	if err != nil || lockedUntil == nil {
+		return err
+	}
+
+	log.Printf("Locked user %s until %s after %d failed logins", user.ID, lockedUntil.Format(time.RFC3339), loginLockoutThreshold)
+	event := newAuditEvent(models.AuditUserLock, models.AuditTargetUser, user.ID)
+	event.Changes = map[string]models.AuditChange{"locked_until": {From: user.LockedUntil, To: lockedUntil}}
+	s.audit.Record(ctx, event)
+
+	// The lockout holds whether or not the email goes out
+	if err := s.sendUnlockEmail(ctx, user); err != nil {
+		log.Printf("Failed to send unlock email to user %s: %v", user.ID, err)
+	}
+	return nil
+}
+
+func (s *AuthService) sendUnlockEmail(ctx context.Context, user *models.User) error {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdateAccountUnlockToken(ctx, user.ID, utils.HashToken(token), time.Now().Add(accountUnlockTTL)); err != nil {
+		return err
+	}
+
+	var link string
+	if s.lockoutOptions.UnlockURL != "" {
+		link = s.lockoutOptions.UnlockURL + "?" + url.Values{"token": {token}}.Encode()
+	}
+	return sendTemplate(ctx, s.mailer, mailer.TemplateAccountLocked, user.Email, map[string]interface{}{
+		"Username":         user.Username,
+		"Token":            token,
+		"Link":             link,
+		"LockedForMinutes": int(loginLockoutDuration.Minutes()),
+		"ExpiresInHours":   int(accountUnlockTTL.Hours()),
+	})
+}
+
+// UnlockAccount lifts a lockout using the token from the lockout email.
+func (s *AuthService) UnlockAccount(ctx context.Context, token, ip string) error {
+	ok, err := s.limiter.Allow(ctx, "unlock-account:ip:"+ip, unlockAccountPerIP, resetThrottleWindow)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("too many requests")
+	}
+
+	userID, err := s.userRepo.UnlockAccountByToken(ctx, utils.HashToken(token))
+	if err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, newUserAuditEvent(models.AuditUserUnlock, userID))
+	return nil
+}
+
+// UnlockUser lifts the lockout of any user. Permission checks are done in
+// the route middleware.
+func (s *AuthService) UnlockUser(ctx context.Context, userID uuid.UUID) error {
+	if err := s.userRepo.UnlockAccount(ctx, userID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, newAuditEvent(models.AuditUserUnlock, models.AuditTargetUser, userID))
+	return nil
+}
+
+// completeLogin finishes a login whose first factor has been checked, by
+// password or an external identity provider.
+func (s *AuthService) completeLogin(ctx context.Context, user *models.User, client *models.Session) (*models.LoginResult, error) {
+	enabled, err := s.mfa.Enabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled || s.mfa.Required(user.Role) {
+		challenge := utils.MFAChallengeClaims{UserID: user.ID, Device: client.Device, Enroll: !enabled}
+		token, err := utils.GenerateMFAChallengeToken(challenge, mfaChallengeTTL)
+		if err != nil {
+			return nil, err
+		}
+		return &models.LoginResult{Challenge: &models.MFAChallenge{
+			Token:              token,
+			ExpiresAt:          time.Now().Add(mfaChallengeTTL),
+			EnrollmentRequired: !enabled,
+		}}, nil
+	}
+
+	tokens, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &models.LoginResult{Tokens: tokens, User: user}, nil
+}
+
+// VerifyMFA completes a login that returned an MFA challenge. code is a TOTP
+// or recovery code; for a challenge that requires enrollment it must be a
+// TOTP code of the pending enrollment, and the new recovery codes are
+// returned with the tokens.
+func (s *AuthService) VerifyMFA(ctx context.Context, mfaToken, code string, client *models.Session) (*models.LoginResult, error) {
+	challenge, err := utils.ValidateMFAChallengeToken(mfaToken)
+	if err != nil {
+		return nil, errors.New("invalid mfa token")
+	}
+
+	var recoveryCodes []string
+	if challenge.Enroll {
+		recoveryCodes, err = s.mfa.ConfirmEnrollment(ctx, challenge.UserID, code)
+	} else {
+		err = s.mfa.Verify(ctx, challenge.UserID, code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserById(ctx, challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	client.Device = challenge.Device
+	tokens, err := s.startSession(ctx, user, client)
+	if err != nil {
+		return nil, err
+	}
+	return &models.LoginResult{Tokens: tokens, User: user, RecoveryCodes: recoveryCodes}, nil
+}
+
+// StartMFAEnrollment begins the enrollment that a challenge with
+// EnrollmentRequired asks for, before the user has an access token.
+func (s *AuthService) StartMFAEnrollment(ctx context.Context, mfaToken string) (*models.MFAEnrollment, error) {
+	challenge, err := utils.ValidateMFAChallengeToken(mfaToken)
+	if err != nil || !challenge.Enroll {
+		return nil, errors.New("invalid mfa token")
+	}
+	return s.mfa.StartEnrollment(ctx, challenge.UserID)
+}
+
+// startSession creates a session for the user and issues its first token
+// pair.
+func (s *AuthService) startSession(ctx context.Context, user *models.User, client *models.Session) (*models.TokenPair, error) {
+	session := &models.Session{
+		UserID:    user.ID,
+		Device:    client.Device,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	}
+	if err := s.sessionRepo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := utils.GenerateAccessToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The session's refresh tokens form one family
+	refreshToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	rt := &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  session.ID,
+		ExpiresAt: session.ExpiresAt,
+	}
+	if err := s.refreshTokenRepo.CreateRefreshToken(ctx, rt, utils.HashToken(refreshToken)); err != nil {
+		return nil, err
+	}
+
+	event := newUserAuditEvent(models.AuditUserLogin, user.ID)
+	event.Changes = map[string]models.AuditChange{"session_id": {To: session.ID}}
+	s.audit.Record(ctx, event)
+
+	return &models.TokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// Refresh exchanges a refresh token for a new token pair. The presented token
+// is rotated and can't be used again.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, error) {
+	nextToken, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	next := &models.RefreshToken{ExpiresAt: time.Now().Add(refreshTokenTTL)}
+	if err := s.refreshTokenRepo.RotateRefreshToken(ctx, utils.HashToken(refreshToken), next, utils.HashToken(nextToken)); err != nil {
+		return nil, err
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
-		return "", nil, errors.New("invalid credentials")
+	if err := s.sessionRepo.TouchSession(ctx, next.FamilyID, next.ExpiresAt); err != nil {
+		return nil, err
 	}
 
-	tokenString, err := utils.GenerateAccessToken(user)
+	// Reload the user so that role changes reach the new access token
+	user, err := s.userRepo.GetUserById(ctx, next.UserID)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := utils.GenerateAccessToken(user, next.FamilyID)
 	if err != nil {
-		return "", nil, err
+		return nil, err
 	}
 
-	return tokenString, user, nil
+	return &models.TokenPair{AccessToken: accessToken, RefreshToken: nextToken}, nil
+}
 
+// Logout revokes the token family of the refresh token, ending that login on
+// every token derived from it.
+func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
+	rt, err := s.refreshTokenRepo.RevokeRefreshTokenFamily(ctx, utils.HashToken(refreshToken))
+	if err != nil || rt == nil {
+		return err
+	}
+
+	// The session shares its ID with the token family
+	event := newSessionRevokeEvent(models.AuditUserLogout, rt.UserID, rt.FamilyID)
+	event.ActorID = &rt.UserID
+	s.audit.Record(ctx, event)
+	return nil
 }
 
-func (s *AuthService) ForgotPassword(ctx context.Context, email string) (string, error) {
+// ForgotPassword mails a reset token to the user. It succeeds whether or not
+// an account exists for the email, so callers can't probe for accounts, and
+// the token is never returned to the caller.
+func (s *AuthService) ForgotPassword(ctx context.Context, email, ip string) error {
+	if err := s.throttle(ctx, "forgot-password", email, forgotPasswordPerEmail, ip, forgotPasswordPerIP); err != nil {
+		return err
+	}
+
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
-		return "", errors.New("user not found")
+		if err.Error() == "user not found" {
+			return nil
+		}
+		return err
 	}
 
-	// Generate 6 digit random number
-	// For simplicity, using a fixed randomizer or simple math. In production, use crypto/rand
-	// Using utils? Or just implement here.
-	resetToken := utils.GenerateRandomNumericString(6)
-	expiresAt := time.Now().Add(60 * time.Minute)
+	var resetToken, link string
+	if s.resetOptions.LinkTokens {
+		if resetToken, err = utils.GenerateRandomToken(32); err != nil {
+			return err
+		}
+		link = s.resetOptions.LinkURL + "?" + url.Values{"email": {user.Email}, "token": {resetToken}}.Encode()
+	} else {
+		resetToken = utils.GenerateRandomNumericString(6)
+	}
+	expiresAt := time.Now().Add(passwordResetTTL)
 
-	if err := s.userRepo.UpdatePasswordResetToken(ctx, user.ID, resetToken, expiresAt); err != nil {
-		return "", err
+	if err := s.userRepo.UpdatePasswordResetToken(ctx, user.ID, s.resetTokenHash(user.ID, resetToken), expiresAt); err != nil {
+		return err
 	}
 
-	return resetToken, nil
+	return sendTemplate(ctx, s.mailer, mailer.TemplatePasswordReset, user.Email, map[string]interface{}{
+		"Username":         user.Username,
+		"Token":            resetToken,
+		"Link":             link,
+		"ExpiresInMinutes": int(passwordResetTTL.Minutes()),
+	})
 }
 
-func (s *AuthService) ResetPassword(ctx context.Context, email, newPassword, token string) error {
+// ResetPassword sets a new password using a token from ForgotPassword. Every
+// failure reports "invalid or expired token" so that it reveals nothing about
+// the account.
+func (s *AuthService) ResetPassword(ctx context.Context, email, newPassword, token, ip string) error {
+	if err := s.throttle(ctx, "reset-password", email, resetPasswordPerEmail, ip, resetPasswordPerIP); err != nil {
+		return err
+	}
+
+	invalid := errors.New("invalid or expired token")
+
 	user, err := s.userRepo.GetUserByEmail(ctx, email)
 	if err != nil {
-		return errors.New("user not found")
+		if err.Error() == "user not found" {
+			// Reject weak passwords as for a real account
+			if err := s.passwords.Check(newPassword, "", email); err != nil {
+				return err
+			}
+			return invalid
+		}
+		return err
+	}
+
+	// Check the password first, so that a rejected one doesn't use up an
+	// attempt at the token
+	if err := s.passwords.Check(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	tokenHash, expiresAt, err := s.userRepo.UsePasswordResetAttempt(ctx, user.ID, resetMaxAttempts)
+	if err != nil {
+		if err.Error() == "invalid token" {
+			return invalid
+		}
+		return err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(tokenHash), []byte(s.resetTokenHash(user.ID, token))) != 1 {
+		return invalid
+	}
+	if time.Now().After(expiresAt) {
+		return invalid
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
 	}
 
-	if user.PasswordResetToken == nil || *user.PasswordResetToken != token {
-		return errors.New("invalid token")
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+		return err
 	}
+	s.bus.Publish(ctx, events.UserChanged{UserID: user.ID})
 
-	if user.PasswordResetExpires == nil || time.Now().After(*user.PasswordResetExpires) {
-		return errors.New("token expired")
+	s.audit.Record(ctx, newUserAuditEvent(models.AuditPasswordReset, user.ID))
+	return nil
+}
+
+// resetTokenHash returns the stored form of a reset token. There are only a
+// million codes, so they are hashed with the server-side key and the user ID
+// rather than on their own.
+func (s *AuthService) resetTokenHash(userID uuid.UUID, token string) string {
+	if s.resetOptions.LinkTokens {
+		return utils.HashToken(token)
 	}
+	return utils.HashTokenWithKey(s.resetOptions.CodeKey, userID.String()+":"+token)
+}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+// throttle applies the per-email and per-IP limits of an action.
+func (s *AuthService) throttle(ctx context.Context, action, email string, emailLimit int, ip string, ipLimit int) error {
+	ok, err := s.limiter.Allow(ctx, action+":email:"+strings.ToLower(email), emailLimit, resetThrottleWindow)
 	if err != nil {
 		return err
 	}
+	if !ok {
+		return errors.New("too many requests")
+	}
 
-	return s.userRepo.UpdatePassword(ctx, user.ID, string(hashedPassword))
+	ok, err = s.limiter.Allow(ctx, action+":ip:"+ip, ipLimit, resetThrottleWindow)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("too many requests")
+	}
+	return nil
 }
 
 func (s *AuthService) ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword string) error {
@@ -115,30 +692,54 @@ func (s *AuthService) ChangePassword(ctx context.Context, userID uuid.UUID, curr
 		return err
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(currentPassword)); err != nil {
+	ok, _, err := s.hasher.Verify(currentPassword, user.Password)
+	if err != nil {
+		return err
+	}
+	if !ok {
 		return errors.New("incorrect current password")
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err := s.passwords.Check(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
 	if err != nil {
 		return err
 	}
 
-	return s.userRepo.UpdatePassword(ctx, userID, string(hashedPassword))
+	if err := s.userRepo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		return err
+	}
+	s.bus.Publish(ctx, events.UserChanged{UserID: userID})
+
+	s.audit.Record(ctx, newAuditEvent(models.AuditPasswordChange, models.AuditTargetUser, userID))
+	return nil
 }
 
 func (s *AuthService) ChangeUserPassword(ctx context.Context, userID uuid.UUID, newPassword string) error {
-	// Admin check is done in handler/middleware. Here we just update.
+	// Permission check is done in the route middleware. Here we just update.
 	// Verify user exists
-	_, err := s.userRepo.GetUserById(ctx, userID)
+	user, err := s.userRepo.GetUserById(ctx, userID)
 	if err != nil {
 		return err
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err := s.passwords.Check(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
 	if err != nil {
 		return err
 	}
 
-	return s.userRepo.UpdatePassword(ctx, userID, string(hashedPassword))
+	if err := s.userRepo.UpdatePassword(ctx, userID, hashedPassword); err != nil {
+		return err
+	}
+	s.bus.Publish(ctx, events.UserChanged{UserID: userID})
+
+	s.audit.Record(ctx, newAuditEvent(models.AuditPasswordSet, models.AuditTargetUser, userID))
+	return nil
 }
@@ -0,0 +1,469 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/policy"
+	"job-portal-api/internal/repository"
+	"job-portal-api/pkg/cloudinary"
+	"job-portal-api/pkg/mailer"
+	"job-portal-api/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+type CompanyService struct {
+	repo       *repository.CompanyRepository
+	userRepo   *repository.UserRepository
+	cldService *cloudinary.Service
+	mailer     mailer.Mailer
+	options    InvitationOptions
+	audit      *AuditService
+}
+
+// InvitationOptions configures the email that carries an invitation token.
+type InvitationOptions struct {
+	// AcceptURL is the page the invitation link opens, with the token
+	// appended as a query parameter. Without it the email contains just the
+	// token.
+	AcceptURL string
+}
+
+func NewCompanyService(repo *repository.CompanyRepository, userRepo *repository.UserRepository, cldService *cloudinary.Service, m mailer.Mailer, options InvitationOptions, audit *AuditService) *CompanyService {
+	return &CompanyService{
+		repo:       repo,
+		userRepo:   userRepo,
+		cldService: cldService,
+		mailer:     m,
+		options:    options,
+		audit:      audit,
+	}
+}
+
+// invitationTTL is how long an invitation token stays valid.
+const invitationTTL = 7 * 24 * time.Hour
+
+var slugInvalidChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// slugify turns a company name into a URL-safe slug such as "acme-inc".
+func slugify(name string) string {
+	return strings.Trim(slugInvalidChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+func (s *CompanyService) CreateCompany(ctx context.Context, company *models.Company, file multipart.File, requestUser *models.User) (*models.Company, error) {
+	if !policy.Can(requestUser, policy.CompaniesCreate) {
+		return nil, errors.New("unauthorized to create companies")
+	}
+	if company.Slug == "" {
+		company.Slug = company.Name
+	}
+	company.Slug = slugify(company.Slug)
+	if company.Slug == "" {
+		return nil, errors.New("invalid company slug")
+	}
+
+	if file != nil {
+		imageUrl, publicID, err := s.cldService.UploadImage(ctx, file, companyLogoID())
+		if err != nil {
+			return nil, err
+		}
+		company.Logo = models.FileUpload{URL: imageUrl, PublicID: publicID}
+	}
+
+	if err := s.repo.CreateCompany(ctx, company, requestUser.ID); err != nil {
+		if company.Logo.PublicID != "" {
+			_ = s.cldService.DeleteImage(ctx, company.Logo.PublicID)
+		}
+		return nil, err
+	}
+	return company, nil
+}
+
+// companyLogoID returns a fresh public ID for an uploaded logo, so that no
+// upload can replace another company's logo.
+func companyLogoID() string {
+	return "company-logos/" + uuid.NewString()
+}
+
+func (s *CompanyService) GetAllCompanies(ctx context.Context) ([]models.Company, error) {
+	return s.repo.GetAllCompanies(ctx)
+}
+
+func (s *CompanyService) GetCompaniesByUser(ctx context.Context, userID uuid.UUID) ([]models.Company, error) {
+	return s.repo.GetCompaniesByUserID(ctx, userID)
+}
+
+func (s *CompanyService) GetCompanyByID(ctx context.Context, id uuid.UUID) (*models.Company, error) {
+	return s.repo.GetCompanyByID(ctx, id)
+}
+
+// UpdateCompany applies the provided fields. Only users allowed to verify
+// companies may change the verified flag, which is passed separately since
+// false is a valid value; they may do so without being members.
+func (s *CompanyService) UpdateCompany(ctx context.Context, id uuid.UUID, updateData *models.Company, verified *bool, file multipart.File, requestUser *models.User) (*models.Company, error) {
+	company, err := s.repo.GetCompanyByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if verified != nil && !policy.Can(requestUser, policy.CompaniesVerify) {
+		return nil, errors.New("unauthorized to verify companies")
+	}
+	verifyOnly := verified != nil && file == nil && *updateData == (models.Company{})
+	if !verifyOnly {
+		ok, err := s.hasRole(ctx, id, requestUser, models.CompanyRoleOwner)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("unauthorized to update this company")
+		}
+	}
+
+	before := *company
+	if updateData.Name != "" {
+		company.Name = updateData.Name
+	}
+	if updateData.Slug != "" {
+		company.Slug = slugify(updateData.Slug)
+		if company.Slug == "" {
+			return nil, errors.New("invalid company slug")
+		}
+	}
+	if updateData.Website != "" {
+		company.Website = updateData.Website
+	}
+	if updateData.Description != "" {
+		company.Description = updateData.Description
+	}
+	if verified != nil {
+		company.Verified = *verified
+	}
+
+	if file != nil {
+		// Delete old logo if it exists
+		if company.Logo.PublicID != "" {
+			_ = s.cldService.DeleteImage(ctx, company.Logo.PublicID)
+		}
+
+		imageUrl, publicID, err := s.cldService.UploadImage(ctx, file, companyLogoID())
+		if err != nil {
+			return nil, err
+		}
+		company.Logo = models.FileUpload{URL: imageUrl, PublicID: publicID}
+	}
+
+	if err := s.repo.UpdateCompany(ctx, company); err != nil {
+		return nil, err
+	}
+
+	changes := auditDiff(&before, company)
+	delete(changes, "updated_at")
+	if len(changes) > 0 {
+		event := newAuditEvent(models.AuditCompanyUpdate, models.AuditTargetCompany, id)
+		event.Changes = changes
+		s.audit.Record(ctx, event)
+	}
+	return company, nil
+}
+
+func (s *CompanyService) DeleteCompany(ctx context.Context, id uuid.UUID, requestUser *models.User) error {
+	company, err := s.repo.GetCompanyByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	ok, err := s.hasRole(ctx, id, requestUser, models.CompanyRoleOwner)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("unauthorized to delete this company")
+	}
+
+	if err := s.repo.DeleteCompany(ctx, id); err != nil {
+		return err
+	}
+	event := newAuditEvent(models.AuditCompanyDelete, models.AuditTargetCompany, id)
+	event.Changes = auditDiff(company, nil)
+	s.audit.Record(ctx, event)
+
+	// Delete logo from Cloudinary if it exists
+	if company.Logo.PublicID != "" {
+		_ = s.cldService.DeleteImage(ctx, company.Logo.PublicID)
+	}
+	return nil
+}
+
+func (s *CompanyService) GetMembers(ctx context.Context, companyID uuid.UUID, requestUser *models.User) ([]models.CompanyMember, error) {
+	if _, err := s.repo.GetCompanyByID(ctx, companyID); err != nil {
+		return nil, err
+	}
+
+	ok, err := s.hasRole(ctx, companyID, requestUser, models.CompanyRoleViewer)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("unauthorized to view this company's members")
+	}
+	return s.repo.GetMembers(ctx, companyID)
+}
+
+// AddMember lets an owner add an existing user directly, without an
+// invitation.
+func (s *CompanyService) AddMember(ctx context.Context, companyID, userID uuid.UUID, role models.CompanyRole, requestUser *models.User) error {
+	if !role.IsValid() {
+		return errors.New("invalid company role")
+	}
+	if _, err := s.repo.GetCompanyByID(ctx, companyID); err != nil {
+		return err
+	}
+
+	ok, err := s.hasRole(ctx, companyID, requestUser, models.CompanyRoleOwner)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("unauthorized to manage this company's members")
+	}
+	if err := s.repo.AddMember(ctx, companyID, userID, role); err != nil {
+		return err
+	}
+
+	s.recordMemberChange(ctx, models.AuditCompanyMemberAdd, companyID, userID, "", role)
+	return nil
+}
+
+func (s *CompanyService) UpdateMemberRole(ctx context.Context, companyID, userID uuid.UUID, role models.CompanyRole, requestUser *models.User) error {
+	if !role.IsValid() {
+		return errors.New("invalid company role")
+	}
+
+	ok, err := s.hasRole(ctx, companyID, requestUser, models.CompanyRoleOwner)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("unauthorized to manage this company's members")
+	}
+
+	if role != models.CompanyRoleOwner {
+		if err := s.ensureNotLastOwner(ctx, companyID, userID); err != nil {
+			return err
+		}
+	}
+
+	previous, err := s.repo.GetMemberRole(ctx, companyID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdateMemberRole(ctx, companyID, userID, role); err != nil {
+		return err
+	}
+
+	s.recordMemberChange(ctx, models.AuditCompanyMemberUpdate, companyID, userID, previous, role)
+	return nil
+}
+
+// RemoveMember removes a member from the company. Members may always remove
+// themselves; removing anyone else requires the owner role.
+func (s *CompanyService) RemoveMember(ctx context.Context, companyID, userID uuid.UUID, requestUser *models.User) error {
+	if userID != requestUser.ID {
+		ok, err := s.hasRole(ctx, companyID, requestUser, models.CompanyRoleOwner)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errors.New("unauthorized to manage this company's members")
+		}
+	}
+
+	if err := s.ensureNotLastOwner(ctx, companyID, userID); err != nil {
+		return err
+	}
+
+	previous, err := s.repo.GetMemberRole(ctx, companyID, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.RemoveMember(ctx, companyID, userID); err != nil {
+		return err
+	}
+
+	s.recordMemberChange(ctx, models.AuditCompanyMemberRemove, companyID, userID, previous, "")
+	return nil
+}
+
+// CreateInvitation issues a single-use invitation token and mails it to the
+// email address, so that only its owner can accept. The database keeps just
+// the token's hash.
+func (s *CompanyService) CreateInvitation(ctx context.Context, companyID uuid.UUID, email string, role models.CompanyRole, requestUser *models.User) (*models.CompanyInvitation, error) {
+	if !role.IsValid() {
+		return nil, errors.New("invalid company role")
+	}
+	if _, err := mail.ParseAddress(email); err != nil {
+		return nil, errors.New("invalid email format")
+	}
+	company, err := s.repo.GetCompanyByID(ctx, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.hasRole(ctx, companyID, requestUser, models.CompanyRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("unauthorized to manage this company's members")
+	}
+
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	inv := &models.CompanyInvitation{
+		CompanyID: companyID,
+		Email:     email,
+		Role:      role,
+		InvitedBy: &requestUser.ID,
+		ExpiresAt: time.Now().Add(invitationTTL),
+	}
+	if err := s.repo.CreateInvitation(ctx, inv, utils.HashToken(token)); err != nil {
+		return nil, err
+	}
+	s.recordInvitationChange(ctx, models.AuditCompanyInvitationCreate, companyID, inv.ID, nil, map[string]string{
+		"email": email,
+		"role":  string(role),
+	})
+
+	var link string
+	if s.options.AcceptURL != "" {
+		link = s.options.AcceptURL + "?" + url.Values{"token": {token}}.Encode()
+	}
+	err = sendTemplate(ctx, s.mailer, mailer.TemplateCompanyInvitation, email, map[string]interface{}{
+		"CompanyName":   company.Name,
+		"Role":          string(role),
+		"Token":         token,
+		"Link":          link,
+		"ExpiresInDays": int(invitationTTL.Hours() / 24),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+func (s *CompanyService) GetPendingInvitations(ctx context.Context, companyID uuid.UUID, requestUser *models.User) ([]models.CompanyInvitation, error) {
+	if _, err := s.repo.GetCompanyByID(ctx, companyID); err != nil {
+		return nil, err
+	}
+
+	ok, err := s.hasRole(ctx, companyID, requestUser, models.CompanyRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("unauthorized to manage this company's members")
+	}
+	return s.repo.GetPendingInvitations(ctx, companyID)
+}
+
+func (s *CompanyService) RevokeInvitation(ctx context.Context, companyID, invitationID uuid.UUID, requestUser *models.User) error {
+	ok, err := s.hasRole(ctx, companyID, requestUser, models.CompanyRoleOwner)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("unauthorized to manage this company's members")
+	}
+	if err := s.repo.RevokeInvitation(ctx, companyID, invitationID); err != nil {
+		return err
+	}
+
+	s.recordInvitationChange(ctx, models.AuditCompanyInvitationRevoke, companyID, invitationID, "pending", "revoked")
+	return nil
+}
+
+// AcceptInvitation adds the logged-in user to the inviting company. The
+// invitation must have been addressed to the user's email.
+func (s *CompanyService) AcceptInvitation(ctx context.Context, token string, requestUser *models.User) (*models.CompanyInvitation, error) {
+	user, err := s.userRepo.GetUserById(ctx, requestUser.ID)
+	if err != nil {
+		return nil, err
+	}
+	inv, err := s.repo.AcceptInvitation(ctx, utils.HashToken(token), user.ID, user.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordInvitationChange(ctx, models.AuditCompanyInvitationAccept, inv.CompanyID, inv.ID, "pending", "accepted")
+	s.recordMemberChange(ctx, models.AuditCompanyMemberAdd, inv.CompanyID, user.ID, "", inv.Role)
+	return inv, nil
+}
+
+// recordInvitationChange audits a change to one of the company's invitations,
+// keyed by the invitation ID.
+func (s *CompanyService) recordInvitationChange(ctx context.Context, action string, companyID, invitationID uuid.UUID, from, to interface{}) {
+	event := newAuditEvent(action, models.AuditTargetCompany, companyID)
+	event.Changes = map[string]models.AuditChange{"invitations." + invitationID.String(): {From: from, To: to}}
+	s.audit.Record(ctx, event)
+}
+
+// recordMemberChange audits a change to the company's members, keyed by the
+// member's user ID. An empty role stands for not being a member.
+func (s *CompanyService) recordMemberChange(ctx context.Context, action string, companyID, userID uuid.UUID, from, to models.CompanyRole) {
+	change := models.AuditChange{}
+	if from != "" {
+		change.From = from
+	}
+	if to != "" {
+		change.To = to
+	}
+
+	event := newAuditEvent(action, models.AuditTargetCompany, companyID)
+	event.Changes = map[string]models.AuditChange{"members." + userID.String(): change}
+	s.audit.Record(ctx, event)
+}
+
+// hasRole reports whether the request user may manage any company or holds
+// at least the given role in the company.
+func (s *CompanyService) hasRole(ctx context.Context, companyID uuid.UUID, requestUser *models.User, min models.CompanyRole) (bool, error) {
+	if policy.Can(requestUser, policy.CompaniesManageAny) {
+		return true, nil
+	}
+	role, err := s.repo.GetMemberRole(ctx, companyID, requestUser.ID)
+	if err != nil {
+		return false, err
+	}
+	return role.AtLeast(min), nil
+}
+
+// ensureNotLastOwner prevents a company from being left without an owner.
+func (s *CompanyService) ensureNotLastOwner(ctx context.Context, companyID, userID uuid.UUID) error {
+	role, err := s.repo.GetMemberRole(ctx, companyID, userID)
+	if err != nil {
+		return err
+	}
+	if role != models.CompanyRoleOwner {
+		return nil
+	}
+
+	owners, err := s.repo.CountOwners(ctx, companyID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return errors.New("a company must keep at least one owner")
+	}
+	return nil
+}
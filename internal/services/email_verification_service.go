@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"job-portal-api/internal/events"
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/repository"
+	"job-portal-api/pkg/mailer"
+	"job-portal-api/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// emailVerificationTTL is how long a verification token stays valid.
+	emailVerificationTTL = 48 * time.Hour
+	// A user may ask for the verification email this often per window.
+	verificationResendLimit  = 3
+	verificationResendWindow = time.Hour
+)
+
+type EmailVerificationService struct {
+	repo     *repository.EmailVerificationRepository
+	userRepo *repository.UserRepository
+	mailer   mailer.Mailer
+	limiter  *RateLimiter
+	bus      *events.Bus
+}
+
+func NewEmailVerificationService(repo *repository.EmailVerificationRepository, userRepo *repository.UserRepository, m mailer.Mailer, limiter *RateLimiter, bus *events.Bus) *EmailVerificationService {
+	return &EmailVerificationService{
+		repo:     repo,
+		userRepo: userRepo,
+		mailer:   m,
+		limiter:  limiter,
+		bus:      bus,
+	}
+}
+
+// SendVerification mails a new verification token for the user's current
+// email address.
+func (s *EmailVerificationService) SendVerification(ctx context.Context, user *models.User) error {
+	token, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.CreateVerification(ctx, user.ID, user.Email, utils.HashToken(token), time.Now().Add(emailVerificationTTL)); err != nil {
+		return err
+	}
+
+	return sendTemplate(ctx, s.mailer, mailer.TemplateVerification, user.Email, map[string]interface{}{
+		"Username":       user.Username,
+		"Token":          token,
+		"ExpiresInHours": int(emailVerificationTTL.Hours()),
+	})
+}
+
+func (s *EmailVerificationService) VerifyEmail(ctx context.Context, token string) error {
+	userID, err := s.repo.VerifyEmail(ctx, utils.HashToken(token))
+	if err != nil {
+		return err
+	}
+
+	s.bus.Publish(ctx, events.UserChanged{UserID: userID})
+	return nil
+}
+
+func (s *EmailVerificationService) ResendVerification(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.GetUserById(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return errors.New("email already verified")
+	}
+
+	ok, err := s.limiter.Allow(ctx, "verification:user:"+userID.String(), verificationResendLimit, verificationResendWindow)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("too many requests")
+	}
+	return s.SendVerification(ctx, user)
+}
@@ -3,29 +3,90 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
 	"mime/multipart"
+	"time"
 
+	"job-portal-api/internal/events"
 	"job-portal-api/internal/models"
+	"job-portal-api/internal/policy"
 	"job-portal-api/internal/repository"
+	"job-portal-api/internal/requestctx"
 	"job-portal-api/pkg/cloudinary"
 
 	"github.com/google/uuid"
 )
 
 type JobService struct {
-	repo       *repository.JobRepository
-	cldService *cloudinary.Service
+	repo        *repository.JobRepository
+	companyRepo *repository.CompanyRepository
+	cldService  *cloudinary.Service
+	bus         *events.Bus
+	audit       *AuditService
 }
 
-func NewJobService(repo *repository.JobRepository, cldService *cloudinary.Service) *JobService {
+func NewJobService(repo *repository.JobRepository, companyRepo *repository.CompanyRepository, cldService *cloudinary.Service, bus *events.Bus, audit *AuditService) *JobService {
 	return &JobService{
-		repo:       repo,
-		cldService: cldService,
+		repo:        repo,
+		companyRepo: companyRepo,
+		cldService:  cldService,
+		bus:         bus,
+		audit:       audit,
 	}
 }
 
-func (s *JobService) CreateJob(ctx context.Context, job *models.Job, file multipart.File, filename string) (*models.Job, error) {
-	if file != nil {
+// jobTransitions lists the statuses each job status may move to through the
+// lifecycle endpoints. Closed jobs are final; expired jobs can be republished
+// with a new expiry date.
+var jobTransitions = map[models.JobStatus][]models.JobStatus{
+	models.JobStatusDraft:     {models.JobStatusPublished, models.JobStatusClosed},
+	models.JobStatusPublished: {models.JobStatusPaused, models.JobStatusClosed},
+	models.JobStatusPaused:    {models.JobStatusPublished, models.JobStatusClosed},
+	models.JobStatusExpired:   {models.JobStatusPublished, models.JobStatusClosed},
+}
+
+// CreateJob posts a job. Jobs of a company may be posted by its recruiters
+// whatever their own role; other jobs need the jobs:create permission.
+func (s *JobService) CreateJob(ctx context.Context, job *models.Job, file multipart.File, filename string, requestUser *models.User) (*models.Job, error) {
+	// Company API keys post for their company only
+	if job.CompanyID == nil {
+		job.CompanyID = apiKeyCompanyID(ctx)
+	}
+	if job.CompanyID == nil && !policy.Can(requestUser, policy.JobsCreate) {
+		return nil, errors.New("unauthorized to post jobs")
+	}
+	if job.Status == "" {
+		job.Status = models.JobStatusPublished
+		if job.PublishAt != nil {
+			job.Status = models.JobStatusDraft
+		}
+	}
+	if job.Status != models.JobStatusDraft && job.Status != models.JobStatusPublished {
+		return nil, errors.New("a new job must be either draft or published")
+	}
+	if job.ExpiresAt != nil && !job.ExpiresAt.After(time.Now()) {
+		return nil, errors.New("expires_at must be in the future")
+	}
+	if job.PublishAt != nil {
+		if job.Status != models.JobStatusDraft {
+			return nil, errors.New("only draft jobs can be scheduled for publishing")
+		}
+		if !job.PublishAt.After(time.Now()) {
+			return nil, errors.New("publish_at must be in the future")
+		}
+	}
+	if job.Status == models.JobStatusPublished {
+		now := time.Now()
+		job.PublishedAt = &now
+	}
+
+	if job.CompanyID != nil {
+		// Jobs of a company take their name and logo from the company profile
+		if err := s.attachCompany(ctx, job, *job.CompanyID, requestUser); err != nil {
+			return nil, err
+		}
+	} else if file != nil {
 		imageUrl, publicID, err := s.cldService.UploadImage(ctx, file, filename)
 		if err != nil {
 			return nil, err
@@ -36,19 +97,58 @@ func (s *JobService) CreateJob(ctx context.Context, job *models.Job, file multip
 	if err := s.repo.CreateJob(ctx, job); err != nil {
 		return nil, err
 	}
+
+	event := newAuditEvent(models.AuditJobCreate, models.AuditTargetJob, job.ID)
+	event.Changes = auditDiff(nil, job)
+	s.audit.Record(ctx, event)
+
+	if job.Status == models.JobStatusPublished {
+		s.bus.Publish(ctx, events.JobPublished{Job: *job, PublishedAt: *job.PublishedAt})
+	}
 	return job, nil
 }
 
-func (s *JobService) GetAllJobs(ctx context.Context) ([]models.Job, error) {
-	return s.repo.GetAllJobs(ctx)
+// GetAllJobs lists the jobs the filter's viewer may see. Company API keys
+// see open jobs only, not the unpublished jobs of their creator.
+func (s *JobService) GetAllJobs(ctx context.Context, filter models.JobFilter) (*models.JobList, error) {
+	if apiKeyCompanyID(ctx) != nil {
+		filter.Viewer = nil
+	}
+	return s.repo.GetAllJobs(ctx, filter)
+}
+
+func (s *JobService) SearchJobs(ctx context.Context, q string, limit, offset int, requestUser *models.User) (*models.JobSearchList, error) {
+	if apiKeyCompanyID(ctx) != nil {
+		requestUser = nil
+	}
+	return s.repo.SearchJobs(ctx, q, limit, offset, requestUser)
+}
+
+func (s *JobService) SuggestJobValues(ctx context.Context, field, prefix string, limit int) ([]models.JobSuggestion, error) {
+	return s.repo.SuggestJobValues(ctx, field, prefix, limit)
 }
 
 func (s *JobService) GetJobsByUser(ctx context.Context, userID uuid.UUID) ([]models.Job, error) {
 	return s.repo.GetJobsByUserID(ctx, userID)
 }
 
-func (s *JobService) GetJobByID(ctx context.Context, id uuid.UUID) (*models.Job, error) {
-	return s.repo.GetJobByID(ctx, id)
+func (s *JobService) GetJobByID(ctx context.Context, id uuid.UUID, requestUser *models.User) (*models.Job, error) {
+	job, err := s.repo.GetJobByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unpublished jobs are hidden from everyone but the people behind them
+	if !job.IsOpen() {
+		ok, err := canAccessJob(ctx, s.companyRepo, job, requestUser, models.CompanyRoleViewer, policy.JobsReadAny)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New("job not found")
+		}
+	}
+	return job, nil
 }
 
 func (s *JobService) UpdateJob(ctx context.Context, jobID uuid.UUID, updateData *models.Job, file multipart.File, filename string, requestUser *models.User) (*models.Job, error) {
@@ -58,9 +158,28 @@ func (s *JobService) UpdateJob(ctx context.Context, jobID uuid.UUID, updateData
 	}
 
 	// Authorization check
-	if !requestUser.IsAdmin && existingJob.UserID != requestUser.ID {
+	ok, err := canAccessJob(ctx, s.companyRepo, existingJob, requestUser, models.CompanyRoleRecruiter, policy.JobsUpdateAny)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
 		return nil, errors.New("unauthorized to update this job")
 	}
+	before := *existingJob
+
+	if existingJob.CompanyID != nil && (updateData.Company != "" || file != nil) {
+		return nil, errors.New("company name and logo are managed on the company profile")
+	}
+	if updateData.CompanyID != nil && (existingJob.CompanyID == nil || *existingJob.CompanyID != *updateData.CompanyID) {
+		oldLogo := existingJob.CompanyLogo
+		if err := s.attachCompany(ctx, existingJob, *updateData.CompanyID, requestUser); err != nil {
+			return nil, err
+		}
+		// A job-specific logo is no longer used once the job joins a company
+		if existingJob.CompanyID != nil && oldLogo.PublicID != "" && oldLogo.PublicID != existingJob.CompanyLogo.PublicID {
+			_ = s.cldService.DeleteImage(ctx, oldLogo.PublicID)
+		}
+	}
 
 	// Update fields only if they are provided
 	if updateData.Title != "" {
@@ -72,8 +191,34 @@ func (s *JobService) UpdateJob(ctx context.Context, jobID uuid.UUID, updateData
 	if updateData.Location != "" {
 		existingJob.Location = updateData.Location
 	}
-	if updateData.Salary != "" {
+	salaryChanged := updateData.HasStructuredSalary() || updateData.SalaryCurrency != "" || updateData.SalaryPeriod != ""
+	if salaryChanged {
+		if updateData.SalaryMin != nil {
+			existingJob.SalaryMin = updateData.SalaryMin
+		}
+		if updateData.SalaryMax != nil {
+			existingJob.SalaryMax = updateData.SalaryMax
+		}
+		if updateData.SalaryCurrency != "" {
+			existingJob.SalaryCurrency = updateData.SalaryCurrency
+		}
+		if updateData.SalaryPeriod != "" {
+			existingJob.SalaryPeriod = updateData.SalaryPeriod
+		}
+	} else if updateData.Salary != "" {
+		// A free-text salary replaces any previous structured range
 		existingJob.Salary = updateData.Salary
+		existingJob.SalaryMin = nil
+		existingJob.SalaryMax = nil
+		existingJob.SalaryCurrency = ""
+		existingJob.SalaryPeriod = ""
+	}
+	// Updates that leave the salary alone don't rewrite it
+	if salaryChanged && existingJob.HasStructuredSalary() {
+		if err := existingJob.ValidateSalary(); err != nil {
+			return nil, err
+		}
+		existingJob.Salary = existingJob.FormatSalary()
 	}
 	if updateData.ExperienceLevel != "" {
 		existingJob.ExperienceLevel = updateData.ExperienceLevel
@@ -87,6 +232,21 @@ func (s *JobService) UpdateJob(ctx context.Context, jobID uuid.UUID, updateData
 	if updateData.Company != "" {
 		existingJob.Company = updateData.Company
 	}
+	if updateData.PublishAt != nil {
+		if existingJob.Status != models.JobStatusDraft {
+			return nil, errors.New("only draft jobs can be scheduled for publishing")
+		}
+		if !updateData.PublishAt.After(time.Now()) {
+			return nil, errors.New("publish_at must be in the future")
+		}
+		existingJob.PublishAt = updateData.PublishAt
+	}
+	if updateData.ExpiresAt != nil {
+		if !updateData.ExpiresAt.After(time.Now()) {
+			return nil, errors.New("expires_at must be in the future")
+		}
+		existingJob.ExpiresAt = updateData.ExpiresAt
+	}
 
 	if file != nil {
 		// Delete old logo if it exists
@@ -104,6 +264,7 @@ func (s *JobService) UpdateJob(ctx context.Context, jobID uuid.UUID, updateData
 	if err := s.repo.UpdateJob(ctx, existingJob); err != nil {
 		return nil, err
 	}
+	s.recordUpdate(ctx, &before, existingJob)
 
 	return existingJob, nil
 }
@@ -114,14 +275,238 @@ func (s *JobService) DeleteJob(ctx context.Context, id uuid.UUID, requestUser *m
 		return err
 	}
 
-	if !requestUser.IsAdmin && existingJob.UserID != requestUser.ID {
+	ok, err := canAccessJob(ctx, s.companyRepo, existingJob, requestUser, models.CompanyRoleRecruiter, policy.JobsDeleteAny)
+	if err != nil {
+		return err
+	}
+	if !ok {
 		return errors.New("unauthorized to delete this job")
 	}
 
-	// Delete logo from Cloudinary if it exists
-	if existingJob.CompanyLogo.PublicID != "" {
+	// Delete logo from Cloudinary if it exists; company logos are shared
+	if existingJob.CompanyID == nil && existingJob.CompanyLogo.PublicID != "" {
 		_ = s.cldService.DeleteImage(ctx, existingJob.CompanyLogo.PublicID)
 	}
 
-	return s.repo.DeleteJob(ctx, id)
+	if err := s.repo.DeleteJob(ctx, id); err != nil {
+		return err
+	}
+
+	event := newAuditEvent(models.AuditJobDelete, models.AuditTargetJob, id)
+	event.Changes = auditDiff(existingJob, nil)
+	s.audit.Record(ctx, event)
+	return nil
+}
+
+func (s *JobService) recordUpdate(ctx context.Context, before, after *models.Job) {
+	changes := auditDiff(before, after)
+	delete(changes, "updated_at")
+	if len(changes) == 0 {
+		return
+	}
+
+	event := newAuditEvent(models.AuditJobUpdate, models.AuditTargetJob, after.ID)
+	event.Changes = changes
+	s.audit.Record(ctx, event)
+}
+
+func (s *JobService) PublishJob(ctx context.Context, id uuid.UUID, expiresAt *time.Time, requestUser *models.User) (*models.Job, error) {
+	return s.transitionJob(ctx, id, models.JobStatusPublished, expiresAt, requestUser)
+}
+
+func (s *JobService) PauseJob(ctx context.Context, id uuid.UUID, requestUser *models.User) (*models.Job, error) {
+	return s.transitionJob(ctx, id, models.JobStatusPaused, nil, requestUser)
+}
+
+func (s *JobService) CloseJob(ctx context.Context, id uuid.UUID, requestUser *models.User) (*models.Job, error) {
+	return s.transitionJob(ctx, id, models.JobStatusClosed, nil, requestUser)
+}
+
+func (s *JobService) transitionJob(ctx context.Context, id uuid.UUID, status models.JobStatus, expiresAt *time.Time, requestUser *models.User) (*models.Job, error) {
+	job, err := s.repo.GetJobByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := canAccessJob(ctx, s.companyRepo, job, requestUser, models.CompanyRoleRecruiter, policy.JobsUpdateAny)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("unauthorized to update this job")
+	}
+	before := *job
+
+	allowed := false
+	for _, next := range jobTransitions[job.Status] {
+		if next == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("invalid status transition from %s to %s", job.Status, status)
+	}
+
+	now := time.Now()
+	if expiresAt != nil {
+		if !expiresAt.After(now) {
+			return nil, errors.New("expires_at must be in the future")
+		}
+		job.ExpiresAt = expiresAt
+	}
+
+	if status == models.JobStatusPublished {
+		if job.ExpiresAt != nil && !job.ExpiresAt.After(now) {
+			return nil, errors.New("expires_at must be in the future")
+		}
+		if job.PublishedAt == nil {
+			job.PublishedAt = &now
+		}
+	}
+
+	// Leaving the draft state by hand cancels any pending schedule
+	job.PublishAt = nil
+	job.Status = status
+	if err := s.repo.UpdateJobStatus(ctx, job); err != nil {
+		return nil, err
+	}
+	s.recordUpdate(ctx, &before, job)
+
+	if status == models.JobStatusPublished {
+		s.bus.Publish(ctx, events.JobPublished{Job: *job, PublishedAt: now})
+	}
+	return job, nil
+}
+
+// RunExpiryWorker periodically expires jobs past their expiry date until ctx
+// is cancelled.
+func (s *JobService) RunExpiryWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.repo.ExpireJobs(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to expire jobs: %v", err)
+			}
+		} else if n > 0 {
+			log.Printf("Expired %d jobs", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scheduledPublishBatchSize bounds how many jobs one scheduler run claims.
+const scheduledPublishBatchSize = 100
+
+// RunPublishScheduler periodically publishes drafts whose publish_at has
+// passed until ctx is cancelled, emitting a JobPublished event for each.
+// Drafts that expired while waiting are expired rather than published.
+func (s *JobService) RunPublishScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.publishScheduledJobs(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *JobService) publishScheduledJobs(ctx context.Context) {
+	for {
+		jobs, err := s.repo.PublishScheduledJobs(ctx, scheduledPublishBatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to publish scheduled jobs: %v", err)
+			}
+			return
+		}
+
+		for _, job := range jobs {
+			if job.Status != models.JobStatusPublished {
+				log.Printf("Scheduled job %s expired before it was published", job.ID)
+				continue
+			}
+			log.Printf("Published scheduled job %s", job.ID)
+			s.bus.Publish(ctx, events.JobPublished{Job: job, PublishedAt: *job.PublishedAt})
+		}
+
+		// A full batch means more jobs may be due
+		if len(jobs) < scheduledPublishBatchSize {
+			return
+		}
+	}
+}
+
+// attachCompany links the job to a company the request user recruits for,
+// copying the company's name and logo onto the job.
+func (s *JobService) attachCompany(ctx context.Context, job *models.Job, companyID uuid.UUID, requestUser *models.User) error {
+	if keyCompanyID := apiKeyCompanyID(ctx); keyCompanyID != nil && *keyCompanyID != companyID {
+		return errors.New("unauthorized to post jobs for this company")
+	}
+
+	company, err := s.companyRepo.GetCompanyByID(ctx, companyID)
+	if err != nil {
+		return err
+	}
+
+	if !policy.Can(requestUser, policy.CompaniesManageAny) {
+		role, err := s.companyRepo.GetMemberRole(ctx, companyID, requestUser.ID)
+		if err != nil {
+			return err
+		}
+		if !role.AtLeast(models.CompanyRoleRecruiter) {
+			return errors.New("unauthorized to post jobs for this company")
+		}
+	}
+
+	job.CompanyID = &company.ID
+	job.Company = company.Name
+	job.CompanyLogo = company.Logo
+	return nil
+}
+
+// canAccessJob reports whether the request user holds at least the given
+// company role for the job. Users granted anyPerm may access every job. Jobs
+// of a company are governed by its membership; jobs without a company only by
+// their poster.
+func canAccessJob(ctx context.Context, companyRepo *repository.CompanyRepository, job *models.Job, requestUser *models.User, min models.CompanyRole, anyPerm policy.Permission) (bool, error) {
+	// Company API keys reach their company's jobs only, whatever their
+	// creator may do
+	if companyID := apiKeyCompanyID(ctx); companyID != nil && (job.CompanyID == nil || *job.CompanyID != *companyID) {
+		return false, nil
+	}
+	if policy.Can(requestUser, anyPerm) {
+		return true, nil
+	}
+	if job.CompanyID == nil {
+		return job.UserID == requestUser.ID, nil
+	}
+
+	role, err := companyRepo.GetMemberRole(ctx, *job.CompanyID, requestUser.ID)
+	if err != nil {
+		return false, err
+	}
+	return role.AtLeast(min), nil
+}
+
+// apiKeyCompanyID returns the company the request's API key is limited to, or
+// nil unless the request is made with a company key.
+func apiKeyCompanyID(ctx context.Context) *uuid.UUID {
+	if info := requestctx.From(ctx); info != nil {
+		return info.APIKeyCompanyID
+	}
+	return nil
 }
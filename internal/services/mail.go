@@ -0,0 +1,16 @@
+package services
+
+import (
+	"context"
+
+	"job-portal-api/pkg/mailer"
+)
+
+// sendTemplate renders the named email template and hands it to the mailer.
+func sendTemplate(ctx context.Context, m mailer.Mailer, name, to string, data map[string]interface{}) error {
+	msg, err := mailer.Render(name, to, data)
+	if err != nil {
+		return err
+	}
+	return m.Send(ctx, msg)
+}
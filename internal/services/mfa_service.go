@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/repository"
+	"job-portal-api/pkg/qrcode"
+	"job-portal-api/pkg/totp"
+	"job-portal-api/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// Second factor checks are limited per user within mfaThrottleWindow, on top
+// of TOTP codes being single use.
+const (
+	mfaMaxAttempts     = 10
+	mfaThrottleWindow  = 15 * time.Minute
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+)
+
+// MFAOptions configures TOTP two-factor authentication.
+type MFAOptions struct {
+	// Issuer is the account name shown in authenticator apps.
+	Issuer string
+	// RequiredRoles must use a second factor; their users have to enroll
+	// before they can log in.
+	RequiredRoles []models.Role
+}
+
+type MFAService struct {
+	repo     *repository.MFARepository
+	userRepo *repository.UserRepository
+	limiter  *RateLimiter
+	options  MFAOptions
+	audit    *AuditService
+}
+
+func NewMFAService(repo *repository.MFARepository, userRepo *repository.UserRepository, limiter *RateLimiter, options MFAOptions, audit *AuditService) *MFAService {
+	return &MFAService{
+		repo:     repo,
+		userRepo: userRepo,
+		limiter:  limiter,
+		options:  options,
+		audit:    audit,
+	}
+}
+
+// Required reports whether users of role must use a second factor.
+func (s *MFAService) Required(role models.Role) bool {
+	for _, r := range s.options.RequiredRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Enabled reports whether the user has a confirmed second factor.
+func (s *MFAService) Enabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	m, err := s.repo.GetMFA(ctx, userID)
+	if err != nil {
+		if err.Error() == "mfa not found" {
+			return false, nil
+		}
+		return false, err
+	}
+	return m.EnabledAt != nil, nil
+}
+
+func (s *MFAService) GetStatus(ctx context.Context, requestUser *models.User) (*models.MFAStatus, error) {
+	status := &models.MFAStatus{Required: s.Required(requestUser.Role)}
+
+	m, err := s.repo.GetMFA(ctx, requestUser.ID)
+	if err != nil {
+		if err.Error() == "mfa not found" {
+			return status, nil
+		}
+		return nil, err
+	}
+	if m.EnabledAt == nil {
+		return status, nil
+	}
+
+	status.Enabled = true
+	status.EnabledAt = m.EnabledAt
+	if status.RecoveryCodesRemaining, err = s.repo.CountRecoveryCodes(ctx, requestUser.ID); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// StartEnrollment generates a new secret for the user. The second factor
+// only takes effect once ConfirmEnrollment accepts a code for it.
+func (s *MFAService) StartEnrollment(ctx context.Context, userID uuid.UUID) (*models.MFAEnrollment, error) {
+	user, err := s.userRepo.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.StartEnrollment(ctx, userID, secret); err != nil {
+		return nil, err
+	}
+
+	uri := totp.URI(s.options.Issuer, user.Email, secret)
+	png, err := qrcode.PNG(uri, 6)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MFAEnrollment{
+		Secret:     secret,
+		OTPAuthURI: uri,
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// ConfirmEnrollment enables the pending second factor if code is valid for
+// it, and returns the user's recovery codes. They are only shown this once.
+func (s *MFAService) ConfirmEnrollment(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	m, err := s.repo.GetMFA(ctx, userID)
+	if err != nil {
+		if err.Error() == "mfa not found" {
+			return nil, errors.New("mfa enrollment not started")
+		}
+		return nil, err
+	}
+	if m.EnabledAt != nil {
+		return nil, errors.New("mfa already enabled")
+	}
+
+	if err := s.throttle(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.checkTOTP(ctx, m, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.EnableMFA(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	// Enrollment may be part of a login, before the user has a session
+	s.audit.Record(ctx, newUserAuditEvent(models.AuditMFAEnable, userID))
+	return codes, nil
+}
+
+// Verify checks a TOTP code or an unused recovery code of the user's
+// enabled second factor.
+func (s *MFAService) Verify(ctx context.Context, userID uuid.UUID, code string) error {
+	m, err := s.repo.GetMFA(ctx, userID)
+	if err != nil {
+		if err.Error() == "mfa not found" {
+			return errors.New("mfa not enabled")
+		}
+		return err
+	}
+	if m.EnabledAt == nil {
+		return errors.New("mfa not enabled")
+	}
+
+	if err := s.throttle(ctx, userID); err != nil {
+		return err
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == 6 {
+		return s.checkTOTP(ctx, m, code)
+	}
+	return s.repo.UseRecoveryCode(ctx, userID, utils.HashToken(normalizeRecoveryCode(code)))
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a
+// code of the second factor.
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, newAuditEvent(models.AuditMFARecoveryCodes, models.AuditTargetUser, userID))
+	return codes, nil
+}
+
+// Disable removes the user's second factor after checking a code of it.
+// Users whose role requires a second factor can't disable it.
+func (s *MFAService) Disable(ctx context.Context, requestUser *models.User, code string) error {
+	if s.Required(requestUser.Role) {
+		return errors.New("mfa is required for your role")
+	}
+	if err := s.Verify(ctx, requestUser.ID, code); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteMFA(ctx, requestUser.ID); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, newAuditEvent(models.AuditMFADisable, models.AuditTargetUser, requestUser.ID))
+	return nil
+}
+
+func (s *MFAService) checkTOTP(ctx context.Context, m *models.UserMFA, code string) error {
+	step, ok := totp.Validate(m.Secret, code, time.Now())
+	if !ok {
+		return errors.New("invalid code")
+	}
+	return s.repo.UseStep(ctx, m.UserID, step)
+}
+
+func (s *MFAService) throttle(ctx context.Context, userID uuid.UUID) error {
+	ok, err := s.limiter.Allow(ctx, "mfa:user:"+userID.String(), mfaMaxAttempts, mfaThrottleWindow)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("too many requests")
+	}
+	return nil
+}
+
+// generateRecoveryCodes returns new recovery codes, formatted for display as
+// "xxxxx-xxxxx", and their hashes.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := utils.GenerateRandomString(recoveryCodeLength, recoveryCodeAlphabet)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		hashes[i] = utils.HashToken(code)
+	}
+	return codes, hashes, nil
+}
+
+// recoveryCodeAlphabet leaves out characters that are easily confused.
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// normalizeRecoveryCode accepts codes with or without the separator and in
+// any case.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
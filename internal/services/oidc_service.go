@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/repository"
+	"job-portal-api/pkg/oidc"
+	"job-portal-api/pkg/utils"
+
+	"github.com/google/uuid"
+)
+
+// OIDCLoginTTL is how long the user has to finish logging in at the provider.
+const OIDCLoginTTL = 10 * time.Minute
+
+// OIDCService logs users in through external OpenID providers. Identities
+// are linked to users by the provider's subject, never by email alone.
+type OIDCService struct {
+	providers map[string]*oidc.Provider
+	repo      *repository.IdentityRepository
+	userRepo  *repository.UserRepository
+	auth      *AuthService
+}
+
+func NewOIDCService(providers map[string]*oidc.Provider, repo *repository.IdentityRepository, userRepo *repository.UserRepository, auth *AuthService) *OIDCService {
+	return &OIDCService{
+		providers: providers,
+		repo:      repo,
+		userRepo:  userRepo,
+		auth:      auth,
+	}
+}
+
+// Providers returns the names of the configured providers.
+func (s *OIDCService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StartLogin records a new authorization request and returns the provider
+// URL to send the user to, along with the request's state. When linkUserID is
+// set, completing the request links the provider to that user instead of
+// logging in.
+func (s *OIDCService) StartLogin(ctx context.Context, providerName string, linkUserID *uuid.UUID) (string, string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", "", errors.New("provider not found")
+	}
+
+	state, err := oidc.GenerateState()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := oidc.GenerateState()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := oidc.GenerateCodeVerifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		log.Printf("Failed to start %s login: %v", providerName, err)
+		return "", "", errors.New("provider unavailable")
+	}
+
+	err = s.repo.CreateLoginState(ctx, utils.HashToken(state), &models.OIDCLoginState{
+		Provider:     providerName,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		UserID:       linkUserID,
+		ExpiresAt:    time.Now().Add(OIDCLoginTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return authURL, state, nil
+}
+
+// Callback completes an authorization request. A login returns the same
+// result as a password login, creating the user on their first login; a link
+// request returns the newly linked identity.
+func (s *OIDCService) Callback(ctx context.Context, providerName, state, code string, client *models.Session) (*models.LoginResult, *models.UserIdentity, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, nil, errors.New("provider not found")
+	}
+
+	flow, err := s.repo.ConsumeLoginState(ctx, utils.HashToken(state))
+	if err != nil {
+		return nil, nil, err
+	}
+	if flow.Provider != providerName {
+		return nil, nil, errors.New("invalid state")
+	}
+
+	tokens, err := provider.Exchange(ctx, code, flow.CodeVerifier)
+	if err != nil {
+		log.Printf("Failed %s login: %v", providerName, err)
+		return nil, nil, errors.New("login with provider failed")
+	}
+	claims, err := provider.VerifyIDToken(ctx, tokens, flow.Nonce)
+	if err != nil {
+		log.Printf("Failed %s login: %v", providerName, err)
+		return nil, nil, errors.New("login with provider failed")
+	}
+
+	if flow.UserID != nil {
+		now := time.Now()
+		identity := &models.UserIdentity{
+			UserID:      *flow.UserID,
+			Provider:    providerName,
+			Subject:     claims.Subject,
+			Email:       claims.Email,
+			LastLoginAt: &now,
+		}
+		if err := s.repo.CreateIdentity(ctx, identity); err != nil {
+			return nil, nil, err
+		}
+
+		// The provider redirects back without the user's access token
+		event := newAuditEvent(models.AuditIdentityLink, models.AuditTargetIdentity, identity.ID)
+		event.ActorID = flow.UserID
+		event.Changes = auditDiff(nil, identity)
+		s.auth.audit.Record(ctx, event)
+		return nil, identity, nil
+	}
+
+	user, err := s.userForIdentity(ctx, providerName, claims)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := s.auth.completeLogin(ctx, user, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, nil, nil
+}
+
+// userForIdentity returns the user linked to the provider account, creating
+// both on the first login.
+func (s *OIDCService) userForIdentity(ctx context.Context, providerName string, claims *oidc.Claims) (*models.User, error) {
+	identity, err := s.repo.GetIdentity(ctx, providerName, claims.Subject)
+	if err == nil {
+		if err := s.repo.TouchIdentity(ctx, identity.ID, claims.Email); err != nil {
+			return nil, err
+		}
+		return s.userRepo.GetUserById(ctx, identity.UserID)
+	}
+	if err.Error() != "identity not found" {
+		return nil, err
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, errors.New("provider did not return a verified email")
+	}
+
+	// Someone else may have registered the address; only its owner can link
+	// the provider, after logging in
+	if _, err := s.userRepo.GetUserByEmail(ctx, claims.Email); err == nil {
+		return nil, errors.New("email already registered")
+	} else if err.Error() != "user not found" {
+		return nil, err
+	}
+
+	// The account has no usable password until the user resets it
+	unusable, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	hashedPassword, err := s.auth.hasher.Hash(unusable)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Email:    claims.Email,
+		Password: hashedPassword,
+		Role:     models.RoleCandidate,
+	}
+	identity = &models.UserIdentity{
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}
+
+	base := usernameFromClaims(claims)
+	for attempt := 0; ; attempt++ {
+		user.Username = base
+		if attempt > 0 {
+			user.Username = base + "-" + utils.GenerateRandomNumericString(4)
+		}
+		err = s.repo.CreateUserWithIdentity(ctx, user, identity)
+		if err == nil || err.Error() != "username already exists" || attempt == 5 {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	event := newUserAuditEvent(models.AuditUserRegister, user.ID)
+	event.Changes = map[string]models.AuditChange{"provider": {To: providerName}}
+	s.auth.audit.Record(ctx, event)
+	return user, nil
+}
+
+// usernameFromClaims picks a username for a new user from the provider's
+// profile.
+func usernameFromClaims(claims *oidc.Claims) string {
+	name := claims.PreferredUsername
+	if name == "" {
+		name = claims.Name
+	}
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+	name = strings.Join(strings.Fields(name), "")
+	if runes := []rune(name); len(runes) > 50 {
+		name = string(runes[:50])
+	}
+	if name == "" {
+		name = "user"
+	}
+	return name
+}
+
+func (s *OIDCService) GetIdentities(ctx context.Context, userID uuid.UUID) ([]models.UserIdentity, error) {
+	return s.repo.GetIdentitiesByUserID(ctx, userID)
+}
+
+func (s *OIDCService) UnlinkIdentity(ctx context.Context, id, userID uuid.UUID) error {
+	if err := s.repo.DeleteIdentity(ctx, id, userID); err != nil {
+		return err
+	}
+
+	s.auth.audit.Record(ctx, newAuditEvent(models.AuditIdentityUnlink, models.AuditTargetIdentity, id))
+	return nil
+}
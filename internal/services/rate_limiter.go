@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"job-portal-api/internal/repository"
+)
+
+// rateLimitRetention is how long counters are kept; it must exceed the
+// longest window in use.
+const rateLimitRetention = 24 * time.Hour
+
+// RateLimiter throttles actions per key using counters stored in Postgres, so
+// limits hold across all server instances.
+type RateLimiter struct {
+	repo *repository.RateLimitRepository
+}
+
+func NewRateLimiter(repo *repository.RateLimitRepository) *RateLimiter {
+	return &RateLimiter{repo: repo}
+}
+
+// Allow records an attempt for key and reports whether it is within limit
+// attempts per window.
+func (l *RateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, error) {
+	count, err := l.repo.Hit(ctx, key, window)
+	if err != nil {
+		return false, err
+	}
+	return count <= limit, nil
+}
+
+// Exceeded reports whether key already has limit attempts in the current
+// window, without recording a new one.
+func (l *RateLimiter) Exceeded(ctx context.Context, key string, limit int, window time.Duration) (bool, error) {
+	count, err := l.repo.Count(ctx, key, window)
+	if err != nil {
+		return false, err
+	}
+	return count >= limit, nil
+}
+
+// RunCleanup periodically deletes old counters until ctx is cancelled.
+func (l *RateLimiter) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := l.repo.DeleteStale(ctx, rateLimitRetention); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to clean up rate limits: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
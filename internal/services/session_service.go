@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"job-portal-api/internal/events"
+	"job-portal-api/internal/models"
+	"job-portal-api/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// sessionCacheTTL bounds how long a revocation or role change can go unnoticed
+// by other server instances.
+const sessionCacheTTL = 30 * time.Second
+
+type sessionCacheEntry struct {
+	userID  uuid.UUID
+	user    *models.User // nil when the session is no longer active
+	expires time.Time
+}
+
+type SessionService struct {
+	repo  *repository.SessionRepository
+	audit *AuditService
+
+	mu    sync.Mutex
+	cache map[uuid.UUID]sessionCacheEntry
+}
+
+func NewSessionService(repo *repository.SessionRepository, audit *AuditService) *SessionService {
+	return &SessionService{
+		repo:  repo,
+		audit: audit,
+		cache: make(map[uuid.UUID]sessionCacheEntry),
+	}
+}
+
+// ValidateSession checks that the session is still active and returns the
+// user's current role and email verification. Results are cached in-process
+// for sessionCacheTTL.
+func (s *SessionService) ValidateSession(ctx context.Context, sessionID, userID uuid.UUID) (*models.User, bool, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	entry, ok := s.cache[sessionID]
+	s.mu.Unlock()
+	if ok && entry.userID == userID && now.Before(entry.expires) {
+		return entry.user, entry.user != nil, nil
+	}
+
+	user, err := s.repo.GetSessionUser(ctx, sessionID, userID)
+	if err != nil && err.Error() != "session not found" {
+		return nil, false, err
+	}
+	entry = sessionCacheEntry{userID: userID, user: user, expires: now.Add(sessionCacheTTL)}
+
+	s.mu.Lock()
+	// Drop stale entries now and then so the cache doesn't grow unbounded
+	if len(s.cache) > 10000 {
+		for id, e := range s.cache {
+			if now.After(e.expires) {
+				delete(s.cache, id)
+			}
+		}
+	}
+	s.cache[sessionID] = entry
+	s.mu.Unlock()
+
+	return user, user != nil, nil
+}
+
+// HandleUserChanged drops the cached sessions of a user whose role or email
+// verification changed.
+func (s *SessionService) HandleUserChanged(ctx context.Context, event events.Event) {
+	changed, ok := event.(events.UserChanged)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, e := range s.cache {
+		if e.userID == changed.UserID {
+			delete(s.cache, id)
+		}
+	}
+}
+
+// GetSessions lists the user's active sessions, flagging the one the request
+// was made with.
+func (s *SessionService) GetSessions(ctx context.Context, userID, currentSessionID uuid.UUID) ([]models.Session, error) {
+	sessions, err := s.repo.GetActiveSessionsByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession ends one of the user's sessions. Its access tokens are
+// rejected immediately by this instance.
+func (s *SessionService) RevokeSession(ctx context.Context, sessionID, userID uuid.UUID) error {
+	if err := s.repo.RevokeSession(ctx, sessionID, userID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.cache, sessionID)
+	s.mu.Unlock()
+
+	s.audit.Record(ctx, newSessionRevokeEvent(models.AuditSessionRevoke, userID, sessionID))
+	return nil
+}
+
+// newSessionRevokeEvent starts an event for the end of one of the user's
+// sessions, keyed by the session ID.
+func newSessionRevokeEvent(action string, userID, sessionID uuid.UUID) *models.AuditEvent {
+	event := newAuditEvent(action, models.AuditTargetUser, userID)
+	event.Changes = map[string]models.AuditChange{"sessions." + sessionID.String(): {From: "active", To: "revoked"}}
+	return event
+}
@@ -2,33 +2,97 @@ package services
 
 import (
 	"context"
+	"errors"
+	"job-portal-api/internal/events"
 	"job-portal-api/internal/models"
+	"job-portal-api/internal/policy"
 	"job-portal-api/internal/repository"
 	"job-portal-api/pkg/cloudinary"
+	"log"
 	"mime/multipart"
+	"net/mail"
 
 	"github.com/google/uuid"
 )
 
 type UserService struct {
-	userRepo *repository.UserRepository
-	jobRepo  *repository.JobRepository
-	cld      *cloudinary.Service
+	userRepo     *repository.UserRepository
+	jobRepo      *repository.JobRepository
+	cld          *cloudinary.Service
+	verification *EmailVerificationService
+	bus          *events.Bus
+	audit        *AuditService
 }
 
-func NewUserService(userRepo *repository.UserRepository, jobRepo *repository.JobRepository, cld *cloudinary.Service) *UserService {
-	return &UserService{userRepo: userRepo, jobRepo: jobRepo, cld: cld}
+func NewUserService(userRepo *repository.UserRepository, jobRepo *repository.JobRepository, cld *cloudinary.Service, verification *EmailVerificationService, bus *events.Bus, audit *AuditService) *UserService {
+	return &UserService{userRepo: userRepo, jobRepo: jobRepo, cld: cld, verification: verification, bus: bus, audit: audit}
 }
 
 func (s *UserService) GetUserById(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	return s.userRepo.GetUserById(ctx, id)
 }
 
-func (s *UserService) UpdateUser(ctx context.Context, user *models.User) error {
-	return s.userRepo.UpdateUser(ctx, user)
+// UpdateUser applies the non-empty fields of updateData. Users may update
+// their own profile; changing the role requires the users:role:update
+// permission. A changed email must be verified again.
+func (s *UserService) UpdateUser(ctx context.Context, id uuid.UUID, updateData *models.User, role *models.Role, requestUser *models.User) (*models.User, error) {
+	if id != requestUser.ID && !policy.Can(requestUser, policy.UsersUpdateAny) {
+		return nil, errors.New("unauthorized to update this user")
+	}
+
+	user, err := s.userRepo.GetUserById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *user
+
+	if updateData.Username != "" {
+		user.Username = updateData.Username
+	}
+	emailChanged := updateData.Email != "" && updateData.Email != user.Email
+	if emailChanged {
+		if _, err := mail.ParseAddress(updateData.Email); err != nil {
+			return nil, errors.New("invalid email format")
+		}
+		user.Email = updateData.Email
+	}
+	if updateData.ProfilePicture != (models.FileUpload{}) {
+		user.ProfilePicture = updateData.ProfilePicture
+	}
+	roleChanged := false
+	if role != nil && *role != user.Role {
+		if !policy.Can(requestUser, policy.UsersRoleUpdate) {
+			return nil, errors.New("unauthorized to change user roles")
+		}
+		if !role.IsValid() {
+			return nil, errors.New("invalid role")
+		}
+		user.Role = *role
+		roleChanged = true
+	}
+
+	if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+		return nil, err
+	}
+	s.recordUpdate(ctx, &before, user)
+
+	// A new address has to be verified again
+	if emailChanged {
+		if err := s.verification.SendVerification(ctx, user); err != nil {
+			log.Printf("Failed to send verification email to user %s: %v", user.ID, err)
+		}
+	}
+	if emailChanged || roleChanged {
+		s.bus.Publish(ctx, events.UserChanged{UserID: user.ID})
+	}
+	return user, nil
 }
 
-func (s *UserService) UploadProfilePicture(ctx context.Context, userID uuid.UUID, file multipart.File) (string, error) {
+func (s *UserService) UploadProfilePicture(ctx context.Context, userID uuid.UUID, file multipart.File, requestUser *models.User) (string, error) {
+	if userID != requestUser.ID && !policy.Can(requestUser, policy.UsersUpdateAny) {
+		return "", errors.New("unauthorized to update this user")
+	}
+
 	// Check if user exists
 	user, err := s.userRepo.GetUserById(ctx, userID)
 	if err != nil {
@@ -42,6 +106,7 @@ func (s *UserService) UploadProfilePicture(ctx context.Context, userID uuid.UUID
 	}
 
 	// Update user record
+	before := *user
 	user.ProfilePicture = models.FileUpload{
 		URL:      imageURL,
 		PublicID: publicID,
@@ -50,15 +115,32 @@ func (s *UserService) UploadProfilePicture(ctx context.Context, userID uuid.UUID
 	if err != nil {
 		return "", err
 	}
+	s.recordUpdate(ctx, &before, user)
 
 	return imageURL, nil
 }
 
+func (s *UserService) recordUpdate(ctx context.Context, before, after *models.User) {
+	changes := auditDiff(before, after)
+	delete(changes, "updated_at")
+	if len(changes) == 0 {
+		return
+	}
+
+	event := newAuditEvent(models.AuditUserUpdate, models.AuditTargetUser, after.ID)
+	event.Changes = changes
+	s.audit.Record(ctx, event)
+}
+
 func (s *UserService) GetAllUsers(ctx context.Context) ([]models.User, error) {
 	return s.userRepo.GetAllUsers(ctx)
 }
 
-func (s *UserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+func (s *UserService) DeleteUser(ctx context.Context, id uuid.UUID, requestUser *models.User) error {
+	if id != requestUser.ID && !policy.Can(requestUser, policy.UsersDeleteAny) {
+		return errors.New("unauthorized to delete this user")
+	}
+
 	user, err := s.userRepo.GetUserById(ctx, id)
 	if err != nil {
 		return err
@@ -77,9 +159,9 @@ func (s *UserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
 		return err
 	}
 
-	// Delete company logos for each job
+	// Delete company logos for each job; logos of company profiles stay
 	for _, job := range jobs {
-		if job.CompanyLogo.PublicID != "" {
+		if job.CompanyID == nil && job.CompanyLogo.PublicID != "" {
 			if err := s.cld.DeleteAsset(ctx, job.CompanyLogo.PublicID); err != nil {
 				// We log or simply return error. Returning error seems safer to ensure consistency,
 				// though it might block deletion if one image fails.
@@ -89,5 +171,12 @@ func (s *UserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
 		}
 	}
 
-	return s.userRepo.DeleteUser(ctx, id)
+	if err := s.userRepo.DeleteUser(ctx, id); err != nil {
+		return err
+	}
+
+	event := newAuditEvent(models.AuditUserDelete, models.AuditTargetUser, id)
+	event.Changes = auditDiff(user, nil)
+	s.audit.Record(ctx, event)
+	return nil
 }
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS applications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job_id UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    cover_letter TEXT NOT NULL DEFAULT '',
+    resume JSONB NOT NULL DEFAULT '{"url": "", "public_id": ""}'::jsonb,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (job_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_applications_user_id ON applications(user_id);
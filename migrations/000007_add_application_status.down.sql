@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS application_status_history;
+ALTER TABLE applications DROP COLUMN status;
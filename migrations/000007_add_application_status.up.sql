@@ -0,0 +1,17 @@
+ALTER TABLE applications ADD COLUMN status VARCHAR(32) NOT NULL DEFAULT 'applied';
+
+CREATE TABLE IF NOT EXISTS application_status_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    from_status VARCHAR(32),
+    to_status VARCHAR(32) NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_application_status_history_application_id ON application_status_history(application_id);
+
+-- Seed the history of existing applications with their initial state
+INSERT INTO application_status_history (application_id, from_status, to_status, actor_id, created_at)
+SELECT id, NULL, 'applied', user_id, created_at FROM applications;
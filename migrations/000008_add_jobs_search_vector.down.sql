@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_jobs_search_vector;
+ALTER TABLE jobs DROP COLUMN search_vector;
+DROP FUNCTION IF EXISTS jobs_skills_to_text(TEXT[]);
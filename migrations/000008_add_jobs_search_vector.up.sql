@@ -0,0 +1,14 @@
+-- array_to_string is only STABLE, so wrap it to be usable in a generated column
+CREATE OR REPLACE FUNCTION jobs_skills_to_text(skills TEXT[]) RETURNS TEXT
+LANGUAGE sql IMMUTABLE AS $$
+    SELECT array_to_string(skills, ' ')
+$$;
+
+ALTER TABLE jobs ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(company, '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(jobs_skills_to_text(skills), '')), 'B') ||
+    setweight(to_tsvector('english', coalesce(description, '')), 'C')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_search_vector ON jobs USING GIN (search_vector);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_jobs_skills_trgm;
+DROP INDEX IF EXISTS idx_jobs_location_trgm;
+DROP INDEX IF EXISTS idx_jobs_company_trgm;
+DROP INDEX IF EXISTS idx_jobs_title_trgm;
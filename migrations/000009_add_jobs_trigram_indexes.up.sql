@@ -0,0 +1,7 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_title_trgm ON jobs USING GIN (title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_jobs_company_trgm ON jobs USING GIN (company gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_jobs_location_trgm ON jobs USING GIN (location gin_trgm_ops);
+-- Skills are matched against the flattened array to narrow down rows before unnesting
+CREATE INDEX IF NOT EXISTS idx_jobs_skills_trgm ON jobs USING GIN (jobs_skills_to_text(skills) gin_trgm_ops);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_jobs_salary;
+ALTER TABLE jobs DROP COLUMN salary_period;
+ALTER TABLE jobs DROP COLUMN salary_currency;
+ALTER TABLE jobs DROP COLUMN salary_max;
+ALTER TABLE jobs DROP COLUMN salary_min;
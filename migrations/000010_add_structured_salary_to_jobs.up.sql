@@ -0,0 +1,59 @@
+ALTER TABLE jobs ADD COLUMN salary_min BIGINT;
+ALTER TABLE jobs ADD COLUMN salary_max BIGINT;
+ALTER TABLE jobs ADD COLUMN salary_currency VARCHAR(3) NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN salary_period VARCHAR(10) NOT NULL DEFAULT '';
+
+-- Best-effort conversion of amounts such as "80,000", "80k" or "1.5K" found in
+-- the free-text salary. Anything that cannot be parsed is left NULL.
+CREATE OR REPLACE FUNCTION migrate_salary_amount(token TEXT) RETURNS BIGINT
+LANGUAGE plpgsql IMMUTABLE AS $$
+DECLARE
+    amount NUMERIC;
+BEGIN
+    amount := rtrim(replace(substring(token from '\d[\d.,]*'), ',', ''), '.')::NUMERIC;
+    IF token ~* 'k$' THEN
+        amount := amount * 1000;
+    END IF;
+    RETURN round(amount);
+EXCEPTION WHEN others THEN
+    RETURN NULL;
+END;
+$$;
+
+UPDATE jobs j
+SET salary_min = parsed.amounts[1],
+    salary_max = COALESCE(parsed.amounts[2], parsed.amounts[1]),
+    salary_currency = parsed.currency,
+    salary_period = CASE
+        WHEN j.salary ~* '(hour|\mhr\M|/\s*h\M)' THEN 'hour'
+        WHEN j.salary ~* '(month|\mmo\M|/\s*m\M)' THEN 'month'
+        ELSE 'year'
+    END
+FROM (
+    SELECT id, ARRAY(
+        SELECT migrate_salary_amount(m[1])
+        FROM regexp_matches(salary, '(\d[\d.,]*(?:\s?[kK]\M)?)', 'g') AS m
+    ) AS amounts,
+    COALESCE(
+        CASE
+            WHEN salary ~ '\$' THEN 'USD'
+            WHEN salary ~ '€' THEN 'EUR'
+            WHEN salary ~ '£' THEN 'GBP'
+            WHEN salary ~ '₦' THEN 'NGN'
+            WHEN salary ~ '₹' THEN 'INR'
+            WHEN salary ~ '¥' THEN 'JPY'
+        END,
+        upper(substring(salary from '(?i)\m(usd|eur|gbp|ngn|inr|jpy|cad|aud|nzd|chf|sek|nok|dkk|pln|czk|zar|kes|ghs|brl|mxn|sgd|hkd|cny|aed)\M')),
+        ''
+    ) AS currency
+    FROM jobs
+) parsed
+WHERE j.id = parsed.id
+  AND parsed.amounts[1] IS NOT NULL
+  -- Without a currency the numbers may not be a salary at all, and a range
+  -- without one doesn't pass validation; such jobs keep the free text only
+  AND parsed.currency <> '';
+
+DROP FUNCTION migrate_salary_amount(TEXT);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_salary ON jobs (salary_currency, salary_period, salary_min, salary_max);
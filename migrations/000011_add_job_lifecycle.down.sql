@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_jobs_status_expires_at;
+ALTER TABLE jobs DROP COLUMN expires_at;
+ALTER TABLE jobs DROP COLUMN published_at;
+ALTER TABLE jobs DROP COLUMN status;
@@ -0,0 +1,8 @@
+-- Existing jobs were live as soon as they were created, so they start out published
+ALTER TABLE jobs ADD COLUMN status VARCHAR(16) NOT NULL DEFAULT 'published';
+ALTER TABLE jobs ADD COLUMN published_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE jobs ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE jobs SET published_at = created_at;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_status_expires_at ON jobs (status, expires_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_jobs_publish_at;
+ALTER TABLE jobs DROP COLUMN publish_at;
@@ -0,0 +1,3 @@
+ALTER TABLE jobs ADD COLUMN publish_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_publish_at ON jobs (publish_at) WHERE status = 'draft' AND publish_at IS NOT NULL;
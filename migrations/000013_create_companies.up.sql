@@ -0,0 +1,64 @@
+CREATE TABLE IF NOT EXISTS companies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(255) NOT NULL UNIQUE,
+    website VARCHAR(255) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    logo JSONB NOT NULL DEFAULT '{"url": "", "public_id": ""}'::jsonb,
+    verified BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS company_members (
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(16) NOT NULL CHECK (role IN ('owner', 'recruiter', 'viewer')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (company_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_company_members_user_id ON company_members(user_id);
+
+ALTER TABLE jobs ADD COLUMN company_id UUID REFERENCES companies(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_jobs_company_id ON jobs(company_id);
+
+-- Turn every distinct company name a user has posted under into a company
+-- owned by that user, and attach the user's jobs to it.
+DO $$
+DECLARE
+    r RECORD;
+    new_id UUID;
+    base_slug TEXT;
+    candidate TEXT;
+    n INT;
+BEGIN
+    FOR r IN
+        SELECT user_id,
+               trim(min(company)) AS name,
+               (array_agg(company_logo ORDER BY created_at DESC))[1] AS logo
+        FROM jobs
+        GROUP BY user_id, lower(trim(company))
+    LOOP
+        base_slug := trim(BOTH '-' FROM lower(regexp_replace(r.name, '[^a-zA-Z0-9]+', '-', 'g')));
+        IF base_slug = '' THEN
+            base_slug := 'company';
+        END IF;
+
+        candidate := base_slug;
+        n := 1;
+        WHILE EXISTS (SELECT 1 FROM companies WHERE slug = candidate) LOOP
+            n := n + 1;
+            candidate := base_slug || '-' || n;
+        END LOOP;
+
+        INSERT INTO companies (name, slug, logo)
+        VALUES (r.name, candidate, COALESCE(r.logo, '{"url": "", "public_id": ""}'::jsonb))
+        RETURNING id INTO new_id;
+
+        INSERT INTO company_members (company_id, user_id, role) VALUES (new_id, r.user_id, 'owner');
+
+        UPDATE jobs SET company_id = new_id
+        WHERE user_id = r.user_id AND lower(trim(company)) = lower(r.name);
+    END LOOP;
+END $$;
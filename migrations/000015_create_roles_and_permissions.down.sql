@@ -0,0 +1,7 @@
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN DEFAULT FALSE;
+UPDATE users SET is_admin = (role = 'admin');
+ALTER TABLE users DROP COLUMN role;
+
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
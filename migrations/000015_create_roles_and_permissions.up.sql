@@ -0,0 +1,63 @@
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(32) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name VARCHAR(64) PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role VARCHAR(32) NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission VARCHAR(64) NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('candidate', 'Job seeker who applies to jobs'),
+    ('employer', 'Posts jobs and manages companies'),
+    ('moderator', 'Reviews and cleans up content across the portal'),
+    ('admin', 'Full access');
+
+INSERT INTO permissions (name, description) VALUES
+    ('jobs:create', 'Post jobs'),
+    ('jobs:read:any', 'View unpublished jobs of anyone'),
+    ('jobs:update:any', 'Edit and change the status of any job'),
+    ('jobs:delete:any', 'Delete any job'),
+    ('applications:create', 'Apply to jobs'),
+    ('applications:read:any', 'View applications to any job'),
+    ('applications:update:any', 'Move any application through the pipeline'),
+    ('companies:create', 'Create companies'),
+    ('companies:manage:any', 'Manage any company and its members'),
+    ('companies:verify', 'Mark companies as verified'),
+    ('users:read:any', 'List and view all users'),
+    ('users:update:any', 'Edit any user profile'),
+    ('users:delete:any', 'Delete any user'),
+    ('users:role:update', 'Change the role of users'),
+    ('users:password:update:any', 'Set the password of any user');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('candidate', 'applications:create'),
+    ('employer', 'jobs:create'),
+    ('employer', 'companies:create'),
+    ('moderator', 'jobs:read:any'),
+    ('moderator', 'jobs:update:any'),
+    ('moderator', 'jobs:delete:any'),
+    ('moderator', 'applications:read:any'),
+    ('moderator', 'companies:verify'),
+    ('moderator', 'users:read:any');
+
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', name FROM permissions;
+
+ALTER TABLE users ADD COLUMN role VARCHAR(32) NOT NULL DEFAULT 'candidate' REFERENCES roles(name);
+
+-- Admins keep their access; anyone who already posted jobs or belongs to a
+-- company becomes an employer.
+UPDATE users SET role = 'admin' WHERE is_admin;
+UPDATE users SET role = 'employer'
+WHERE role = 'candidate'
+  AND (id IN (SELECT user_id FROM jobs) OR id IN (SELECT user_id FROM company_members));
+
+ALTER TABLE users DROP COLUMN is_admin;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS rate_limits;
+
+ALTER TABLE users DROP COLUMN IF EXISTS password_reset_attempts;
+ALTER TABLE users ALTER COLUMN password_reset_token_hash TYPE VARCHAR(255);
+UPDATE users SET password_reset_token_hash = NULL, password_reset_expires = NULL;
+ALTER TABLE users RENAME COLUMN password_reset_token_hash TO password_reset_token;
@@ -0,0 +1,14 @@
+-- Reset tokens were stored in plaintext; drop the pending ones
+ALTER TABLE users RENAME COLUMN password_reset_token TO password_reset_token_hash;
+UPDATE users SET password_reset_token_hash = NULL, password_reset_expires = NULL;
+ALTER TABLE users ALTER COLUMN password_reset_token_hash TYPE VARCHAR(64);
+ALTER TABLE users ADD COLUMN password_reset_attempts INT NOT NULL DEFAULT 0;
+
+-- Fixed-window counters shared by all instances for throttling
+CREATE TABLE IF NOT EXISTS rate_limits (
+    key VARCHAR(255) PRIMARY KEY,
+    window_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    count INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limits_window_start ON rate_limits(window_start);
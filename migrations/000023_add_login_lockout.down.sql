@@ -0,0 +1,10 @@
+DELETE FROM role_permissions WHERE permission = 'users:unlock:any';
+DELETE FROM permissions WHERE name = 'users:unlock:any';
+
+DROP TABLE IF EXISTS unknown_login_attempts;
+DROP INDEX IF EXISTS idx_users_account_unlock_token_hash;
+ALTER TABLE users DROP COLUMN IF EXISTS account_unlock_expires;
+ALTER TABLE users DROP COLUMN IF EXISTS account_unlock_token_hash;
+ALTER TABLE users DROP COLUMN IF EXISTS locked_until;
+ALTER TABLE users DROP COLUMN IF EXISTS last_failed_login_at;
+ALTER TABLE users DROP COLUMN IF EXISTS failed_login_attempts;
@@ -0,0 +1,24 @@
+-- Failed logins are counted per account so that delays and lockouts hold
+-- across all instances
+ALTER TABLE users ADD COLUMN failed_login_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN last_failed_login_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN locked_until TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN account_unlock_token_hash VARCHAR(64);
+ALTER TABLE users ADD COLUMN account_unlock_expires TIMESTAMP WITH TIME ZONE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_account_unlock_token_hash ON users(account_unlock_token_hash);
+
+-- The same for emails without an account, so that delays and lockouts don't
+-- tell which emails are registered
+CREATE TABLE IF NOT EXISTS unknown_login_attempts (
+    email VARCHAR(255) PRIMARY KEY,
+    failed_login_attempts INT NOT NULL DEFAULT 0,
+    last_failed_login_at TIMESTAMP WITH TIME ZONE,
+    locked_until TIMESTAMP WITH TIME ZONE
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('users:unlock:any', 'Unlock accounts locked after failed logins');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'users:unlock:any');
@@ -0,0 +1,5 @@
+DELETE FROM role_permissions WHERE permission = 'users:impersonate';
+DELETE FROM permissions WHERE name = 'users:impersonate';
+
+DROP TABLE IF EXISTS impersonation_requests;
+ALTER TABLE sessions DROP COLUMN IF EXISTS impersonator_id;
@@ -0,0 +1,27 @@
+-- Sessions an admin opened as another user through impersonation
+ALTER TABLE sessions ADD COLUMN impersonator_id UUID REFERENCES users(id) ON DELETE CASCADE;
+
+-- Every request made while impersonating. Users aren't referenced by foreign
+-- keys so that the trail outlives the accounts.
+CREATE TABLE IF NOT EXISTS impersonation_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    impersonator_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    session_id UUID NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    status INT NOT NULL,
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    remote_ip VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_requests_impersonator_id ON impersonation_requests(impersonator_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_impersonation_requests_user_id ON impersonation_requests(user_id, created_at);
+
+INSERT INTO permissions (name, description) VALUES
+    ('users:impersonate', 'Act as another user to see what they see');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'users:impersonate');
@@ -47,3 +47,26 @@ func (s *Service) DeleteImage(ctx context.Context, publicID string) error {
 func (s *Service) DeleteAsset(ctx context.Context, publicID string) error {
 	return s.DeleteImage(ctx, publicID)
 }
+
+// UploadRaw stores a file that isn't an image, such as a document, as is.
+// The public ID should carry the file's extension so the URL serves it with
+// the right type.
+func (s *Service) UploadRaw(ctx context.Context, file multipart.File, publicID string) (string, string, error) {
+	resp, err := s.cld.Upload.Upload(ctx, file, uploader.UploadParams{
+		Folder:       "job-portal",
+		PublicID:     publicID,
+		ResourceType: "raw",
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return resp.SecureURL, resp.PublicID, nil
+}
+
+func (s *Service) DeleteRaw(ctx context.Context, publicID string) error {
+	_, err := s.cld.Upload.Destroy(ctx, uploader.DestroyParams{
+		PublicID:     publicID,
+		ResourceType: "raw",
+	})
+	return err
+}
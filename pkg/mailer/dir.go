@@ -0,0 +1,35 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DirMailer writes each message as an .eml file into a directory instead of
+// sending it. It is meant for development and tests.
+type DirMailer struct {
+	dir  string
+	from string
+}
+
+func NewDirMailer(dir, from string) (*DirMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	return &DirMailer{dir: dir, from: from}, nil
+}
+
+func (m *DirMailer) Send(ctx context.Context, msg Message) error {
+	body, err := encode(m.from, msg)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000"), uuid.NewString())
+	return os.WriteFile(filepath.Join(m.dir, name), body, 0o644)
+}
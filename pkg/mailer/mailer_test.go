@@ -0,0 +1,120 @@
+package mailer
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func parse(t *testing.T, raw []byte) *mail.Message {
+	t.Helper()
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	return m
+}
+
+func decodeQP(t *testing.T, r io.Reader) string {
+	t.Helper()
+	b, err := io.ReadAll(quotedprintable.NewReader(r))
+	if err != nil {
+		t.Fatalf("decoding quoted-printable: %v", err)
+	}
+	return string(b)
+}
+
+func TestEncodeHeaders(t *testing.T) {
+	raw, err := encode("no-reply@example.com", Message{To: "jane@example.com", Subject: "Grüße aus München", Text: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := parse(t, raw)
+
+	for header, want := range map[string]string{
+		"From":         "no-reply@example.com",
+		"To":           "jane@example.com",
+		"MIME-Version": "1.0",
+	} {
+		if got := m.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	if strings.Contains(m.Header.Get("Subject"), "ü") {
+		t.Error("Subject is not encoded")
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "Grüße aus München" {
+		t.Errorf("Subject = %q", subject)
+	}
+	if _, err := m.Header.Date(); err != nil {
+		t.Errorf("Date: %v", err)
+	}
+}
+
+func TestEncodePlainText(t *testing.T) {
+	text := "Héllo,\n" + strings.Repeat("a long line ", 20)
+	raw, err := encode("no-reply@example.com", Message{To: "jane@example.com", Subject: "Hi", Text: text})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := parse(t, raw)
+
+	if got := m.Header.Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := m.Header.Get("Content-Transfer-Encoding"); got != "quoted-printable" {
+		t.Errorf("Content-Transfer-Encoding = %q", got)
+	}
+	// Line breaks go out as CRLF
+	want := strings.ReplaceAll(text, "\n", "\r\n")
+	if got := decodeQP(t, m.Body); got != want {
+		t.Errorf("body = %q, want %q", got, want)
+	}
+}
+
+func TestEncodeWithHTML(t *testing.T) {
+	msg := Message{To: "jane@example.com", Subject: "Hi", Text: "Plain ünïcode", HTML: "<p>Rich ünïcode</p>"}
+	raw, err := encode("no-reply@example.com", msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := parse(t, raw)
+
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", mediaType)
+	}
+
+	mr := multipart.NewReader(m.Body, params["boundary"])
+	for _, want := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		part, err := mr.NextRawPart()
+		if err != nil {
+			t.Fatalf("reading %s part: %v", want.contentType, err)
+		}
+		if got := part.Header.Get("Content-Type"); got != want.contentType {
+			t.Errorf("part Content-Type = %q, want %q", got, want.contentType)
+		}
+		if got := decodeQP(t, part); got != want.body {
+			t.Errorf("%s part = %q, want %q", want.contentType, got, want.body)
+		}
+	}
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Errorf("expected two parts, got more (%v)", err)
+	}
+}
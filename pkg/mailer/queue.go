@@ -0,0 +1,103 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	queueMaxAttempts  = 5
+	queueRetryBackoff = 2 * time.Second
+	queueDrainTimeout = 10 * time.Second
+)
+
+// Queue sends messages in the background through another Mailer, retrying
+// failed deliveries with exponential backoff. It is itself a Mailer, so
+// callers don't wait on the mail server.
+type Queue struct {
+	mailer  Mailer
+	msgs    chan Message
+	backoff time.Duration // Wait before the first retry
+}
+
+func NewQueue(m Mailer, size int) *Queue {
+	return &Queue{
+		mailer:  m,
+		msgs:    make(chan Message, size),
+		backoff: queueRetryBackoff,
+	}
+}
+
+// Send enqueues the message. It fails only when the queue is full.
+func (q *Queue) Send(ctx context.Context, msg Message) error {
+	select {
+	case q.msgs <- msg:
+		return nil
+	default:
+		return errors.New("mail queue is full")
+	}
+}
+
+// Run delivers queued messages with the given number of workers until ctx is
+// cancelled, then makes one last attempt at the messages still queued.
+func (q *Queue) Run(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case msg := <-q.msgs:
+					q.deliver(ctx, msg)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), queueDrainTimeout)
+	defer cancel()
+	for {
+		select {
+		case msg := <-q.msgs:
+			if err := q.mailer.Send(drainCtx, msg); err != nil {
+				log.Printf("Dropping mail to %s on shutdown: %v", msg.To, err)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (q *Queue) deliver(ctx context.Context, msg Message) {
+	backoff := q.backoff
+	for attempt := 1; ; attempt++ {
+		err := q.mailer.Send(ctx, msg)
+		if err == nil {
+			return
+		}
+		if attempt == queueMaxAttempts {
+			log.Printf("Giving up on mail to %s after %d attempts: %v", msg.To, attempt, err)
+			return
+		}
+		log.Printf("Failed to send mail to %s (attempt %d), retrying in %s: %v", msg.To, attempt, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			// Put it back for the shutdown drain
+			select {
+			case q.msgs <- msg:
+			default:
+			}
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,140 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyMailer fails its first sends, as many as failures, and records when
+// each send was attempted.
+type flakyMailer struct {
+	mu        sync.Mutex
+	failures  int
+	attempts  []time.Time
+	delivered []Message
+	done      chan struct{}
+}
+
+func newFlakyMailer(failures int) *flakyMailer {
+	return &flakyMailer{failures: failures, done: make(chan struct{}, 16)}
+}
+
+func (m *flakyMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts = append(m.attempts, time.Now())
+	if len(m.attempts) <= m.failures {
+		if len(m.attempts) == queueMaxAttempts {
+			m.done <- struct{}{}
+		}
+		return errors.New("server unavailable")
+	}
+	m.delivered = append(m.delivered, msg)
+	m.done <- struct{}{}
+	return nil
+}
+
+func (m *flakyMailer) wait(t *testing.T) {
+	t.Helper()
+	select {
+	case <-m.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for delivery")
+	}
+}
+
+func (m *flakyMailer) snapshot() ([]time.Time, []Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]time.Time(nil), m.attempts...), append([]Message(nil), m.delivered...)
+}
+
+func runQueue(t *testing.T, q *Queue) (cancel func()) {
+	t.Helper()
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		q.Run(ctx, 1)
+		close(stopped)
+	}()
+	return func() {
+		cancelCtx()
+		<-stopped
+	}
+}
+
+func TestQueueRetriesWithBackoff(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		failures  int
+		attempts  int
+		delivered bool
+	}{
+		{"first attempt", 0, 1, true},
+		{"after two failures", 2, 3, true},
+		{"on the last attempt", queueMaxAttempts - 1, queueMaxAttempts, true},
+		{"gives up", queueMaxAttempts + 5, queueMaxAttempts, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newFlakyMailer(tt.failures)
+			q := NewQueue(m, 1)
+			q.backoff = 5 * time.Millisecond
+			stop := runQueue(t, q)
+
+			if err := q.Send(context.Background(), Message{To: "jane@example.com"}); err != nil {
+				t.Fatal(err)
+			}
+			m.wait(t)
+			stop()
+
+			attempts, delivered := m.snapshot()
+			if len(attempts) != tt.attempts {
+				t.Errorf("%d attempts, want %d", len(attempts), tt.attempts)
+			}
+			if got := len(delivered) == 1; got != tt.delivered {
+				t.Errorf("delivered = %v, want %v", got, tt.delivered)
+			}
+
+			// The wait doubles after every failure
+			want := q.backoff
+			for i := 1; i < len(attempts); i++ {
+				if gap := attempts[i].Sub(attempts[i-1]); gap < want {
+					t.Errorf("retry %d after %s, want at least %s", i, gap, want)
+				}
+				want *= 2
+			}
+		})
+	}
+}
+
+func TestQueueDrainsOnShutdown(t *testing.T) {
+	m := newFlakyMailer(0)
+	q := NewQueue(m, 3)
+
+	// Queued before Run, then cancelled right away: the drain delivers them
+	for i := 0; i < 3; i++ {
+		if err := q.Send(context.Background(), Message{To: "jane@example.com"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	q.Run(ctx, 1)
+
+	if _, delivered := m.snapshot(); len(delivered) != 3 {
+		t.Errorf("delivered %d messages, want 3", len(delivered))
+	}
+}
+
+func TestQueueSendFailsWhenFull(t *testing.T) {
+	q := NewQueue(newFlakyMailer(0), 1)
+	if err := q.Send(context.Background(), Message{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Send(context.Background(), Message{}); err == nil {
+		t.Error("Send succeeded on a full queue")
+	}
+}
@@ -0,0 +1,67 @@
+package mailer
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// silentServer accepts connections and never answers, like a stalled mail
+// server.
+func silentServer(t *testing.T) (host, port string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+
+	host, port, err = net.SplitHostPort(ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return host, port
+}
+
+func TestSMTPSendStopsOnCancel(t *testing.T) {
+	host, port := silentServer(t)
+	m := NewSMTPMailer(host, port, "", "", "no-reply@example.com")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := m.Send(ctx, Message{To: "jane@example.com", Subject: "Hi", Text: "hi"})
+	if err != context.Canceled {
+		t.Errorf("Send = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Send returned after %s", elapsed)
+	}
+}
+
+func TestSMTPSendTimesOut(t *testing.T) {
+	host, port := silentServer(t)
+	m := NewSMTPMailer(host, port, "", "", "no-reply@example.com")
+	m.timeout = 50 * time.Millisecond
+
+	start := time.Now()
+	err := m.Send(context.Background(), Message{To: "jane@example.com", Subject: "Hi", Text: "hi"})
+	if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+		t.Errorf("Send = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Send returned after %s", elapsed)
+	}
+}
@@ -0,0 +1,51 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Each email has a "<name>.txt" template, which also defines the
+// "<name>.subject" template, and a "<name>.html" template.
+//
+//go:embed templates
+var templateFS embed.FS
+
+var (
+	textTemplates = texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/*.txt"))
+	htmlTemplates = htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/*.html"))
+)
+
+// Template names
+const (
+	TemplateVerification        = "verification"
+	TemplatePasswordReset       = "password_reset"
+	TemplateApplicationReceived = "application_received"
+	TemplateStatusChanged       = "status_changed"
+	TemplateAccountLocked       = "account_locked"
+	TemplateCompanyInvitation   = "company_invitation"
+)
+
+// Render builds the message for the named template addressed to to.
+func Render(name, to string, data interface{}) (Message, error) {
+	var subject, text, html bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&subject, name+".subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := textTemplates.ExecuteTemplate(&text, name+".txt", data); err != nil {
+		return Message{}, err
+	}
+	if err := htmlTemplates.ExecuteTemplate(&html, name+".html", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()),
+		HTML:    html.String(),
+	}, nil
+}
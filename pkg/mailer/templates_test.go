@@ -0,0 +1,96 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		data    map[string]interface{}
+		subject string
+		text    []string // Substrings of both the text and the HTML body
+	}{
+		{
+			TemplateVerification,
+			map[string]interface{}{"Username": "jane", "Token": "tok123", "ExpiresInHours": 48},
+			"Verify your email address",
+			[]string{"jane", "tok123", "48 hours"},
+		},
+		{
+			TemplatePasswordReset,
+			map[string]interface{}{"Username": "jane", "Token": "tok123", "ExpiresInMinutes": 30},
+			"Reset your password",
+			[]string{"jane", "tok123", "30"},
+		},
+		{
+			TemplateApplicationReceived,
+			map[string]interface{}{"Username": "bob", "CandidateName": "jane", "JobTitle": "Go Developer"},
+			"New application for Go Developer",
+			[]string{"jane", "Go Developer"},
+		},
+		{
+			TemplateStatusChanged,
+			map[string]interface{}{"Username": "jane", "JobTitle": "Go Developer", "Status": "shortlisted"},
+			"Your application for Go Developer was updated",
+			[]string{"jane", "shortlisted"},
+		},
+		{
+			TemplateAccountLocked,
+			map[string]interface{}{"Username": "jane", "LockedForMinutes": 30, "Token": "tok123", "ExpiresInHours": 24},
+			"Your account has been locked",
+			[]string{"jane", "30"},
+		},
+		{
+			TemplateCompanyInvitation,
+			map[string]interface{}{"CompanyName": "Acme", "Role": "recruiter", "Token": "tok123", "ExpiresInDays": 7},
+			"You're invited to join Acme",
+			[]string{"Acme", "recruiter", "tok123"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := Render(tt.name, "jane@example.com", tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if msg.To != "jane@example.com" {
+				t.Errorf("To = %q", msg.To)
+			}
+			if msg.Subject != tt.subject {
+				t.Errorf("Subject = %q, want %q", msg.Subject, tt.subject)
+			}
+			for _, body := range []string{msg.Text, msg.HTML} {
+				if strings.Contains(body, "<no value>") {
+					t.Errorf("body uses a missing field:\n%s", body)
+				}
+				for _, s := range tt.text {
+					if !strings.Contains(body, s) {
+						t.Errorf("body lacks %q:\n%s", s, body)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	msg, err := Render(TemplateVerification, "jane@example.com", map[string]interface{}{
+		"Username": "<script>alert(1)</script>", "Token": "tok123", "ExpiresInHours": 48,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(msg.HTML, "<script>") {
+		t.Errorf("HTML body isn't escaped:\n%s", msg.HTML)
+	}
+	if !strings.Contains(msg.Text, "<script>") {
+		t.Errorf("text body was escaped:\n%s", msg.Text)
+	}
+}
+
+func TestRenderUnknownTemplate(t *testing.T) {
+	if _, err := Render("no_such_template", "jane@example.com", nil); err == nil {
+		t.Error("Render accepted an unknown template")
+	}
+}
@@ -0,0 +1,77 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// prefixLength is the number of hex characters of the SHA-1 hash that name a
+// range file, as in the Have I Been Pwned range API.
+const prefixLength = 5
+
+// BreachedList looks passwords up in a local copy of a breached password
+// corpus split by hash prefix (k-anonymity). The directory holds one file per
+// prefix, named like "A94A8" or "A94A8.txt", with lines of the form
+// "SUFFIX:COUNT" where SUFFIX is the rest of the upper case SHA-1 hex digest.
+// Only the range file of the password's prefix is read, so the corpus never
+// has to fit in memory and can be refreshed in place.
+type BreachedList struct {
+	dir string
+}
+
+// OpenBreachedList checks that dir exists and returns a list backed by it.
+func OpenBreachedList(dir string) (*BreachedList, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("breached password list %s is not a directory", dir)
+	}
+	return &BreachedList{dir: dir}, nil
+}
+
+// Contains reports whether the password appears in the list. Entries with a
+// count of 0, which some downloads add as padding, don't count; entries
+// without a count do.
+func (l *BreachedList) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+
+	f, err := l.openRange(prefix)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		entry, count, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if strings.EqualFold(entry, suffix) {
+			return count == "" || strings.TrimLeft(count, "0") != "", nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+	return false, nil
+}
+
+func (l *BreachedList) openRange(prefix string) (*os.File, error) {
+	f, err := os.Open(filepath.Join(l.dir, prefix+".txt"))
+	if errors.Is(err, fs.ErrNotExist) {
+		f, err = os.Open(filepath.Join(l.dir, prefix))
+	}
+	return f, err
+}
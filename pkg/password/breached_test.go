@@ -0,0 +1,54 @@
+package password
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBreachedListContains(t *testing.T) {
+	// SHA-1 digests:
+	//   password    5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	//   123456      7C4A8D09CA3762AF61E59520943DC26494F8941B
+	//   letmein     B7A875FC1EA228B9061041B7CEC4BD3C52AB3CE3
+	list := writeBreachedList(t, map[string]string{
+		// With the .txt extension, lower case and Windows line endings
+		"5BAA6.txt": "0000000000000000000000000000000000A:1\r\n1e4c9b93f3f0682250b6cf8331b7ee68fd8:3\r\n",
+		// Without an extension; a zero count is padding
+		"7C4A8": "D09CA3762AF61E59520943DC26494F8941B:0\n",
+		"B7A87": "5FC1EA228B9061041B7CEC4BD3C52AB3CE3\n",
+	})
+
+	for _, tt := range []struct {
+		password string
+		want     bool
+	}{
+		{"password", true},
+		{"123456", false},
+		{"letmein", true},
+		{"not in any range file", false},
+	} {
+		got, err := list.Contains(tt.password)
+		if err != nil {
+			t.Fatalf("Contains(%q): %v", tt.password, err)
+		}
+		if got != tt.want {
+			t.Errorf("Contains(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestOpenBreachedListRequiresDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := OpenBreachedList(filepath.Join(dir, "missing")); err == nil {
+		t.Error("OpenBreachedList accepted a missing directory")
+	}
+
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenBreachedList(file); err == nil {
+		t.Error("OpenBreachedList accepted a file")
+	}
+}
@@ -0,0 +1,132 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnknownHash is returned for stored hashes in a format the Hasher can't
+// verify.
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// Argon2idParams are the cost parameters of new Argon2id hashes. Memory is in
+// KiB.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams follow the second recommended option of RFC 9106
+// (64 MiB of memory, 3 passes).
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// b64 is the unpadded standard base64 encoding the PHC string format uses.
+var b64 = base64.RawStdEncoding
+
+// Hasher hashes passwords with Argon2id into PHC strings such as
+// "$argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>". It still verifies bcrypt
+// hashes ("$2a$", "$2b$", "$2y$") made before the switch, and reports when a
+// stored hash should be replaced because it uses another algorithm or
+// parameters.
+type Hasher struct {
+	params Argon2idParams
+	slots  chan struct{}
+}
+
+// NewHasher returns a Hasher that computes at most concurrency Argon2id hashes
+// at once; others wait their turn. Each hash holds params.Memory KiB while it
+// runs, so this caps the memory a burst of logins can take.
+func NewHasher(params Argon2idParams, concurrency int) *Hasher {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Hasher{params: params, slots: make(chan struct{}, concurrency)}
+}
+
+// idKey computes an Argon2id key once a slot is free.
+func (h *Hasher) idKey(password, salt []byte, p Argon2idParams) []byte {
+	h.slots <- struct{}{}
+	defer func() { <-h.slots }()
+	return argon2.IDKey(password, salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+}
+
+// Hash returns the PHC string of a new Argon2id hash of password.
+func (h *Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	p := h.params
+	key := h.idKey([]byte(password), salt, p)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Iterations, p.Parallelism, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+// Verify reports whether password matches the stored hash and, if it does,
+// whether the hash should be replaced by a new one from Hash.
+func (h *Hasher) Verify(password, encoded string) (ok, rehash bool, err error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return h.verifyArgon2id(password, encoded)
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) || errors.Is(err, bcrypt.ErrPasswordTooLong) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		return true, true, nil
+	default:
+		return false, false, ErrUnknownHash
+	}
+}
+
+func (h *Hasher) verifyArgon2id(password, encoded string) (bool, bool, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, hash
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return false, false, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false, ErrUnknownHash
+	}
+	var p Argon2idParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return false, false, ErrUnknownHash
+	}
+	salt, err := b64.DecodeString(parts[4])
+	if err != nil {
+		return false, false, ErrUnknownHash
+	}
+	key, err := b64.DecodeString(parts[5])
+	if err != nil || len(key) == 0 || p.Iterations == 0 || p.Parallelism == 0 {
+		return false, false, ErrUnknownHash
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+
+	computed := h.idKey([]byte(password), salt, p)
+	if subtle.ConstantTimeCompare(computed, key) != 1 {
+		return false, false, nil
+	}
+	return true, p != h.params, nil
+}
@@ -0,0 +1,113 @@
+package password
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testParams keep the tests fast; they are far too weak for real use.
+var testParams = Argon2idParams{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestHashVerifyRoundTrip(t *testing.T) {
+	h := NewHasher(testParams, 1)
+
+	encoded, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Errorf("Hash = %s, want an Argon2id PHC string with the test parameters", encoded)
+	}
+
+	ok, rehash, err := h.Verify("correct horse", encoded)
+	if err != nil || !ok || rehash {
+		t.Errorf("Verify(right password) = %v, %v, %v; want true, false, nil", ok, rehash, err)
+	}
+	ok, _, err = h.Verify("wrong horse", encoded)
+	if err != nil || ok {
+		t.Errorf("Verify(wrong password) = %v, %v; want false, nil", ok, err)
+	}
+}
+
+func TestHashUsesNewSalt(t *testing.T) {
+	h := NewHasher(testParams, 1)
+	a, _ := h.Hash("same password")
+	b, _ := h.Hash("same password")
+	if a == b {
+		t.Error("two hashes of the same password are equal")
+	}
+}
+
+func TestVerifyBcryptAsksForRehash(t *testing.T) {
+	h := NewHasher(testParams, 1)
+	legacy, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, rehash, err := h.Verify("correct horse", string(legacy))
+	if err != nil || !ok || !rehash {
+		t.Errorf("Verify(bcrypt, right password) = %v, %v, %v; want true, true, nil", ok, rehash, err)
+	}
+	ok, rehash, err = h.Verify("wrong horse", string(legacy))
+	if err != nil || ok || rehash {
+		t.Errorf("Verify(bcrypt, wrong password) = %v, %v, %v; want false, false, nil", ok, rehash, err)
+	}
+}
+
+func TestVerifyAsksForRehashWhenParamsChange(t *testing.T) {
+	old := NewHasher(testParams, 1)
+	encoded, err := old.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stronger := testParams
+	stronger.Iterations = 2
+	ok, rehash, err := NewHasher(stronger, 1).Verify("correct horse", encoded)
+	if err != nil || !ok || !rehash {
+		t.Errorf("Verify with new parameters = %v, %v, %v; want true, true, nil", ok, rehash, err)
+	}
+}
+
+func TestVerifyRejectsUnknownHashes(t *testing.T) {
+	h := NewHasher(testParams, 1)
+	for _, encoded := range []string{
+		"",
+		"plaintext",
+		"$argon2i$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0$aGFzaA",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHRzYWx0$aGFzaA",
+		"$argon2id$v=19$m=64,t=0,p=1$c2FsdHNhbHRzYWx0$aGFzaA",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHRzYWx0",
+	} {
+		if _, _, err := h.Verify("password", encoded); err != ErrUnknownHash {
+			t.Errorf("Verify(%q) err = %v, want ErrUnknownHash", encoded, err)
+		}
+	}
+}
+
+func TestHasherConcurrentUse(t *testing.T) {
+	h := NewHasher(testParams, 2)
+	encoded, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if ok, _, err := h.Verify("correct horse", encoded); err != nil || !ok {
+				t.Errorf("Verify = %v, %v; want true, nil", ok, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := len(h.slots); n != 0 {
+		t.Errorf("%d hashing slots still held", n)
+	}
+}
@@ -0,0 +1,147 @@
+// Package password decides which passwords users may choose and hashes them
+// for storage.
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CharClass is a kind of character a policy can require.
+type CharClass string
+
+const (
+	Lower  CharClass = "lower"
+	Upper  CharClass = "upper"
+	Digit  CharClass = "digit"
+	Symbol CharClass = "symbol"
+)
+
+func (c CharClass) IsValid() bool {
+	switch c {
+	case Lower, Upper, Digit, Symbol:
+		return true
+	}
+	return false
+}
+
+func (c CharClass) matches(r rune) bool {
+	switch c {
+	case Lower:
+		return unicode.IsLower(r)
+	case Upper:
+		return unicode.IsUpper(r)
+	case Digit:
+		return unicode.IsDigit(r)
+	case Symbol:
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}
+	return false
+}
+
+// Reason codes of a Violation
+const (
+	ReasonTooShort      = "too_short"
+	ReasonTooLong       = "too_long"
+	ReasonMissingLower  = "missing_lower"
+	ReasonMissingUpper  = "missing_upper"
+	ReasonMissingDigit  = "missing_digit"
+	ReasonMissingSymbol = "missing_symbol"
+	ReasonContainsName  = "contains_username"
+	ReasonContainsEmail = "contains_email"
+	ReasonBreached      = "breached"
+)
+
+// Violation is one reason a password was rejected.
+type Violation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// PolicyError lists every rule a password breaks.
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	return "password does not meet policy"
+}
+
+// identityMinLength is the shortest username or email part that passwords
+// may not contain; shorter ones would reject too many passwords by accident.
+const identityMinLength = 3
+
+// Policy holds the rules passwords must follow. Lengths count characters,
+// not bytes.
+type Policy struct {
+	MinLength       int
+	MaxLength       int
+	RequiredClasses []CharClass
+	// DisallowIdentity rejects passwords that contain the username, the email
+	// address or its local part.
+	DisallowIdentity bool
+	// Breached, if set, rejects passwords found in known data breaches.
+	Breached *BreachedList
+}
+
+// Check returns a *PolicyError listing the rules the password breaks, or nil
+// if it is acceptable. username and email identify the account and may be
+// empty when unknown.
+func (p *Policy) Check(password, username, email string) error {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, Violation{ReasonTooShort, fmt.Sprintf("must be at least %d characters long", p.MinLength)})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, Violation{ReasonTooLong, fmt.Sprintf("must be at most %d characters long", p.MaxLength)})
+	}
+
+	for _, class := range p.RequiredClasses {
+		if !strings.ContainsFunc(password, class.matches) {
+			violations = append(violations, classViolations[class])
+		}
+	}
+
+	if p.DisallowIdentity {
+		lower := strings.ToLower(password)
+		if containsPart(lower, username) {
+			violations = append(violations, Violation{ReasonContainsName, "must not contain your username"})
+		}
+		local, _, _ := strings.Cut(email, "@")
+		if containsPart(lower, email) || containsPart(lower, local) {
+			violations = append(violations, Violation{ReasonContainsEmail, "must not contain your email address"})
+		}
+	}
+
+	// Only look the password up when it is otherwise acceptable
+	if len(violations) == 0 && p.Breached != nil {
+		breached, err := p.Breached.Contains(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			violations = append(violations, Violation{ReasonBreached, "has appeared in a data breach; choose a different password"})
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+var classViolations = map[CharClass]Violation{
+	Lower:  {ReasonMissingLower, "must contain a lowercase letter"},
+	Upper:  {ReasonMissingUpper, "must contain an uppercase letter"},
+	Digit:  {ReasonMissingDigit, "must contain a digit"},
+	Symbol: {ReasonMissingSymbol, "must contain a symbol"},
+}
+
+func containsPart(lowerPassword, part string) bool {
+	part = strings.ToLower(strings.TrimSpace(part))
+	return utf8.RuneCountInString(part) >= identityMinLength && strings.Contains(lowerPassword, part)
+}
@@ -0,0 +1,96 @@
+package password
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func violationCodes(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("Check returned %v, want a *PolicyError", err)
+	}
+	var codes []string
+	for _, v := range policyErr.Violations {
+		codes = append(codes, v.Code)
+	}
+	return codes
+}
+
+func TestPolicyCheck(t *testing.T) {
+	p := &Policy{
+		MinLength:        8,
+		MaxLength:        16,
+		RequiredClasses:  []CharClass{Lower, Upper, Digit, Symbol},
+		DisallowIdentity: true,
+	}
+
+	for _, tt := range []struct {
+		name     string
+		password string
+		want     []string
+	}{
+		{"acceptable", "Tr0ub4dor&3", nil},
+		{"too short", "Ab1!", []string{ReasonTooShort}},
+		{"too long", "Abcdefgh1!Abcdefgh1!", []string{ReasonTooLong}},
+		{"length counts characters", "Ää1!Ää1!", nil},
+		{"missing classes", "abcdefghij", []string{ReasonMissingUpper, ReasonMissingDigit, ReasonMissingSymbol}},
+		{"contains username", "xJaneDoe1!", []string{ReasonContainsName}},
+		{"contains email local part", "Jdoe1999!x", []string{ReasonContainsEmail}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := violationCodes(t, p.Check(tt.password, "janedoe", "jdoe@example.com"))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check(%q) = %v, want %v", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyCheckIgnoresShortOrMissingIdentity(t *testing.T) {
+	p := &Policy{MinLength: 8, DisallowIdentity: true}
+	if err := p.Check("joe-password", "jo", ""); err != nil {
+		t.Errorf("Check = %v, want nil for a username below the minimum length", err)
+	}
+	if err := p.Check("any password", "", ""); err != nil {
+		t.Errorf("Check = %v, want nil without a username or email", err)
+	}
+}
+
+func TestPolicyCheckBreached(t *testing.T) {
+	// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	list := writeBreachedList(t, map[string]string{
+		"5BAA6.txt": "1E4C9B93F3F0682250B6CF8331B7EE68FD8:9659365\n",
+	})
+	p := &Policy{MinLength: 8, Breached: list}
+
+	if got := violationCodes(t, p.Check("password", "", "")); !reflect.DeepEqual(got, []string{ReasonBreached}) {
+		t.Errorf("Check(breached) = %v, want [%s]", got, ReasonBreached)
+	}
+	// Passwords that already break a rule aren't looked up
+	if got := violationCodes(t, p.Check("pass", "", "")); !reflect.DeepEqual(got, []string{ReasonTooShort}) {
+		t.Errorf("Check(short) = %v, want [%s]", got, ReasonTooShort)
+	}
+}
+
+func writeBreachedList(t *testing.T, files map[string]string) *BreachedList {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list, err := OpenBreachedList(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
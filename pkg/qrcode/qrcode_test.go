@@ -0,0 +1,173 @@
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// Symbols cross-checked against an independent encoder, which picked the same
+// mask for these inputs. "#" is a dark module.
+var golden = []struct {
+	content string
+	rows    []string
+}{
+	{
+		content: "hello world",
+		rows: []string{
+			"#######..#.##.#######",
+			"#.....#...#...#.....#",
+			"#.###.#.####..#.###.#",
+			"#.###.#.###.#.#.###.#",
+			"#.###.#.#.#.#.#.###.#",
+			"#.....#.#..#..#.....#",
+			"#######.#.#.#.#######",
+			"........#.#..........",
+			"#.#####..#.#..#####..",
+			".##.##.#.#.########.#",
+			"#.#.####.##.###..###.",
+			"#.#..#...#.###..###..",
+			"...#.#####..###.....#",
+			"........#.#.#...##..#",
+			"#######....#..#...##.",
+			"#.....#.#....#.#.####",
+			"#.###.#.#..#..##....#",
+			"#.###.#.##..######...",
+			"#.###.#.##..#..#..#..",
+			"#.....#..##.##..###..",
+			"#######.##.##.#.#..#.",
+		},
+	},
+	{
+		content: "the quick brown fox jumps over the lazy dog, then naps in the sun for a while",
+		rows: []string{
+			"#######........##.####.#..#.#.#######",
+			"#.....#....##...........#..#..#.....#",
+			"#.###.#.####...#....####...#..#.###.#",
+			"#.###.#.#..#..######.....###..#.###.#",
+			"#.###.#.#...##....###..#......#.###.#",
+			"#.....#.#..#..##......#.#.##..#.....#",
+			"#######.#.#.#.#.#.#.#.#.#.#.#.#######",
+			"........#.#..#.......##.#...#........",
+			"#.#####...#...#...##..#.###...#####..",
+			"#.##....#..#.####.######.......#.#...",
+			"#####.###...###...#.##..####....##.##",
+			"...###...#.####.#.#..#..#...##.##...#",
+			"...#..#.###.###..#.#..#..##...#.#.#..",
+			"####.#.#.##.###.######.#.#..#..#.....",
+			"..###.###....###.#.........##.###...#",
+			"#..#....#..#...####.##......###..#..#",
+			".##.##########.##......#####.##.####.",
+			"...#.#.####.#.##.####..###..##.#.....",
+			"#...####.#..#..##.....#.#####.#..#.##",
+			"..#.##.#..##...#..#..###..##..#.....#",
+			"#.##.####.#.#..#.#.##.#####.###.#####",
+			"###.....#.#..#..#.######..#.#..#.#...",
+			"#..####.#..#...#..#..#....###.##.#..#",
+			"...###.##..###.#.....#....#.#.#.#..#.",
+			"#.#.#.##..##..##..#...#.##...##.#.###",
+			"#.##.#.#..#.##.##..###.#.#..##...###.",
+			"#.#.###.#...#.#...#.#.#....#..#.##.##",
+			"#.###..#..#..#..#..###.....#...##..##",
+			"#.###.#.#.###....#....#..#.#########.",
+			"........#...###..#.#.#.#...##...##...",
+			"#######....#.#.###...#..#...#.#.#..##",
+			"#.....#.#..#..##.###.####...#...#..#.",
+			"#.###.#.#..##.#.#...#..#.########.##.",
+			"#.###.#.##.#.#.#.#####.#..#####.#...#",
+			"#.###.#.###.##.####..##....#.#.#...##",
+			"#.....#..#..#..#..#..####..##.##....#",
+			"#######.##.##..###..#.#.####.#.#.####",
+		},
+	},
+}
+
+func TestEncodeGolden(t *testing.T) {
+	for _, g := range golden {
+		q, err := Encode(g.content)
+		if err != nil {
+			t.Fatalf("Encode(%q): %v", g.content, err)
+		}
+		if q.Size() != len(g.rows) {
+			t.Fatalf("Encode(%q) size = %d, want %d", g.content, q.Size(), len(g.rows))
+		}
+		for y, row := range g.rows {
+			var got strings.Builder
+			for x := 0; x < q.Size(); x++ {
+				if q.Dark(x, y) {
+					got.WriteByte('#')
+				} else {
+					got.WriteByte('.')
+				}
+			}
+			if got.String() != row {
+				t.Errorf("Encode(%q) row %d:\n got %s\nwant %s", g.content, y, got.String(), row)
+			}
+		}
+	}
+}
+
+func TestEncodePicksSmallestVersion(t *testing.T) {
+	for _, tt := range []struct {
+		length  int
+		version int
+	}{
+		{0, 1},
+		{14, 1},
+		{15, 2},
+		{106, 6},
+		{107, 7},
+		{666, 20},
+	} {
+		q, err := Encode(strings.Repeat("a", tt.length))
+		if err != nil {
+			t.Fatalf("Encode of %d bytes: %v", tt.length, err)
+		}
+		if want := 17 + 4*tt.version; q.Size() != want {
+			t.Errorf("Encode of %d bytes: size %d, want %d (version %d)", tt.length, q.Size(), want, tt.version)
+		}
+	}
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	if _, err := Encode(strings.Repeat("a", 667)); !errors.Is(err, ErrTooLong) {
+		t.Errorf("Encode of 667 bytes: err = %v, want ErrTooLong", err)
+	}
+}
+
+func TestEncodeDrawsFinderPatterns(t *testing.T) {
+	q, err := Encode("otpauth://totp/Job%20Portal:jane@example.com?secret=JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := q.Size() - 7
+	for _, corner := range [][2]int{{0, 0}, {last, 0}, {0, last}} {
+		for dy := 0; dy < 7; dy++ {
+			for dx := 0; dx < 7; dx++ {
+				// Dark outer ring, light ring, dark 3x3 centre
+				ring := max(abs(dx-3), abs(dy-3))
+				want := ring != 2
+				if got := q.Dark(corner[0]+dx, corner[1]+dy); got != want {
+					t.Fatalf("finder at %v: module (%d, %d) dark = %v, want %v", corner, dx, dy, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestPNG(t *testing.T) {
+	b, err := PNG("hello world", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Version 1 is 21 modules, plus the quiet zone on both sides
+	if want := (21 + 2*quietZone) * 3; img.Bounds().Dx() != want || img.Bounds().Dy() != want {
+		t.Errorf("PNG bounds = %v, want %dx%d", img.Bounds(), want, want)
+	}
+}
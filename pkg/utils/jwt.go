@@ -2,42 +2,89 @@ package utils
 
 import (
 	"errors"
-	"os"
 	"time"
 
 	"job-portal-api/internal/models"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-func GenerateAccessToken(user *models.User) (string, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return "", errors.New("jwt secret not configured")
+// Every token names its type in the typ claim and its audience in aud. Other
+// services verifying tokens against the published JWKS should check both, so
+// that an MFA challenge is never taken for an access token.
+const (
+	accessTokenType      = "access"
+	accessTokenAudience  = "job-portal-api"
+	mfaChallengeType     = "mfa_challenge"
+	mfaChallengeAudience = "job-portal-api/mfa"
+)
+
+// GenerateAccessToken issues a JWT for the user within the given session. The
+// sid claim lets the auth middleware reject tokens of revoked sessions.
+func GenerateAccessToken(user *models.User, sessionID uuid.UUID) (string, error) {
+	ks, err := currentJWTKeys()
+	if err != nil {
+		return "", err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	now := time.Now()
+	return ks.sign(jwt.MapClaims{
+		"typ":      accessTokenType,
+		"aud":      accessTokenAudience,
+		"jti":      uuid.NewString(),
+		"sid":      sessionID.String(),
 		"user_id":  user.ID.String(),
 		"username": user.Username,
-		"is_admin": user.IsAdmin,
-		"exp":      time.Now().Add(time.Hour * 1).Unix(),
+		"role":     string(user.Role),
+		"iat":      now.Unix(),
+		"exp":      now.Add(time.Hour * 1).Unix(),
 	})
+}
 
-	return token.SignedString([]byte(secret))
+// GenerateImpersonationToken issues an access token for the user within an
+// impersonation session. The act claim (RFC 8693) names the admin acting as
+// the user and the admin's own session, which must stay active for the token
+// to be accepted.
+func GenerateImpersonationToken(user *models.User, sessionID, actorID, actorSessionID uuid.UUID, ttl time.Duration) (string, error) {
+	ks, err := currentJWTKeys()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	return ks.sign(jwt.MapClaims{
+		"typ":      accessTokenType,
+		"aud":      accessTokenAudience,
+		"jti":      uuid.NewString(),
+		"sid":      sessionID.String(),
+		"user_id":  user.ID.String(),
+		"username": user.Username,
+		"role":     string(user.Role),
+		"act": map[string]string{
+			"sub": actorID.String(),
+			"sid": actorSessionID.String(),
+		},
+		"iat": now.Unix(),
+		"exp": now.Add(ttl).Unix(),
+	})
 }
 
+// ValidateAccessToken verifies an access token, rejecting tokens of any
+// other type.
 func ValidateAccessToken(tokenString string) (jwt.MapClaims, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return nil, errors.New("jwt secret not configured")
+	return validateToken(tokenString, accessTokenType, accessTokenAudience)
+}
+
+// validateToken verifies the token's signature and expiry and that it has the
+// given type and audience.
+func validateToken(tokenString, typ, audience string) (jwt.MapClaims, error) {
+	ks, err := currentJWTKeys()
+	if err != nil {
+		return nil, err
 	}
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return []byte(secret), nil
-	})
+	token, err := jwt.Parse(tokenString, ks.keyFunc, jwt.WithAudience(audience))
 
 	if err != nil {
 		return nil, err
@@ -51,6 +98,62 @@ func ValidateAccessToken(tokenString string) (jwt.MapClaims, error) {
 	if !ok {
 		return nil, errors.New("invalid token claims")
 	}
+	if t, _ := claims["typ"].(string); t != typ {
+		return nil, errors.New("invalid token type")
+	}
+	if jti, _ := claims["jti"].(string); uuid.Validate(jti) != nil {
+		return nil, errors.New("invalid token claims")
+	}
 
 	return claims, nil
 }
+
+// MFAChallengeClaims identify a login that passed the password check and
+// still needs a second factor.
+type MFAChallengeClaims struct {
+	// ID is the token's jti; it is set by ValidateMFAChallengeToken.
+	ID     uuid.UUID
+	UserID uuid.UUID
+	Device string
+	// Enroll is set when the user must set up a second factor first.
+	Enroll bool
+}
+
+// GenerateMFAChallengeToken issues a short-lived token that proves the
+// password step of a login.
+func GenerateMFAChallengeToken(challenge MFAChallengeClaims, ttl time.Duration) (string, error) {
+	ks, err := currentJWTKeys()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	return ks.sign(jwt.MapClaims{
+		"typ":     mfaChallengeType,
+		"aud":     mfaChallengeAudience,
+		"jti":     uuid.NewString(),
+		"user_id": challenge.UserID.String(),
+		"device":  challenge.Device,
+		"enroll":  challenge.Enroll,
+		"iat":     now.Unix(),
+		"exp":     now.Add(ttl).Unix(),
+	})
+}
+
+func ValidateMFAChallengeToken(tokenString string) (*MFAChallengeClaims, error) {
+	claims, err := validateToken(tokenString, mfaChallengeType, mfaChallengeAudience)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDStr, _ := claims["user_id"].(string)
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return nil, errors.New("invalid token claims")
+	}
+	jti, _ := claims["jti"].(string)
+	device, _ := claims["device"].(string)
+	enroll, _ := claims["enroll"].(bool)
+
+	return &MFAChallengeClaims{ID: uuid.MustParse(jti), UserID: userID, Device: device, Enroll: enroll}, nil
+}
@@ -0,0 +1,245 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTKey is a key used to sign or verify access tokens.
+type JWTKey struct {
+	ID        string
+	Method    jwt.SigningMethod
+	Private   crypto.Signer // nil for verify-only keys
+	PublicKey crypto.PublicKey
+}
+
+// JWTKeySet holds the signing key and every key still accepted for
+// verification.
+//
+// Keys are read from JWT_KEYS_DIR, one PEM file per key named "<kid>.pem".
+// JWT_SIGNING_KID selects the key that signs new tokens; the other keys only
+// verify. To rotate:
+//
+//  1. Add the new key file and restart with JWT_SIGNING_KID unchanged, so the
+//     JWKS publishes the new public key while the old key keeps signing.
+//  2. Wait at least JWKSMaxAge, until every verifier's cached JWKS has the new
+//     key. Switching earlier gets tokens signed with it rejected elsewhere.
+//  3. Point JWT_SIGNING_KID at the new key and restart.
+//  4. Remove the old file once tokens signed with it have expired.
+//
+// Without JWT_KEYS_DIR tokens are signed with HS256 and JWT_SECRET, and the
+// JWKS is empty.
+type JWTKeySet struct {
+	signing *JWTKey
+	keys    map[string]*JWTKey
+	secret  []byte
+}
+
+var (
+	jwtKeysMu sync.RWMutex
+	jwtKeys   *JWTKeySet
+)
+
+// LoadJWTKeys reads the keys from the environment and makes them the active
+// key set.
+func LoadJWTKeys() error {
+	ks, err := loadJWTKeySet()
+	if err != nil {
+		return err
+	}
+	jwtKeysMu.Lock()
+	jwtKeys = ks
+	jwtKeysMu.Unlock()
+	return nil
+}
+
+// currentJWTKeys returns the active key set, loading it on first use.
+func currentJWTKeys() (*JWTKeySet, error) {
+	jwtKeysMu.RLock()
+	ks := jwtKeys
+	jwtKeysMu.RUnlock()
+	if ks != nil {
+		return ks, nil
+	}
+	if err := LoadJWTKeys(); err != nil {
+		return nil, err
+	}
+	jwtKeysMu.RLock()
+	defer jwtKeysMu.RUnlock()
+	return jwtKeys, nil
+}
+
+func loadJWTKeySet() (*JWTKeySet, error) {
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			return nil, errors.New("jwt secret not configured")
+		}
+		return &JWTKeySet{secret: []byte(secret)}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &JWTKeySet{keys: make(map[string]*JWTKey, len(files))}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read jwt key: %w", err)
+		}
+		kid := strings.TrimSuffix(filepath.Base(file), ".pem")
+		key, err := parseJWTKey(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwt key %s: %w", kid, err)
+		}
+		ks.keys[kid] = key
+	}
+
+	kid := os.Getenv("JWT_SIGNING_KID")
+	signing, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("jwt signing key %q not found in %s", kid, dir)
+	}
+	if signing.Private == nil {
+		return nil, fmt.Errorf("jwt signing key %q has no private key", kid)
+	}
+	ks.signing = signing
+	return ks, nil
+}
+
+// parseJWTKey accepts PKCS#8 and PKCS#1 private keys and PKIX public keys
+// holding an RSA or Ed25519 key.
+func parseJWTKey(kid string, data []byte) (*JWTKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &JWTKey{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.PublicKey = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.PublicKey = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, errors.New("only RSA and Ed25519 keys are supported")
+	}
+	return key, nil
+}
+
+// sign signs the claims with the signing key, setting its kid in the header.
+func (ks *JWTKeySet) sign(claims jwt.Claims) (string, error) {
+	if ks.signing == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.secret)
+	}
+	token := jwt.NewWithClaims(ks.signing.Method, claims)
+	token.Header["kid"] = ks.signing.ID
+	return token.SignedString(ks.signing.Private)
+}
+
+// keyFunc looks up the verification key by kid and checks that the token was
+// signed with the algorithm of that key.
+func (ks *JWTKeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	if ks.signing == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return ks.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return key.PublicKey, nil
+}
+
+// JWK is a public key in JSON Web Key format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKSMaxAge is how long clients may cache the JWKS.
+const JWKSMaxAge = 5 * time.Minute
+
+// JWKS returns the public keys that verify access tokens, for other services
+// to validate tokens without sharing a secret.
+func JWKS() ([]JWK, error) {
+	ks, err := currentJWTKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	kids := make([]string, 0, len(ks.keys))
+	for kid := range ks.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwks := make([]JWK, 0, len(kids))
+	for _, kid := range kids {
+		key := ks.keys[kid]
+		jwk := JWK{Kid: kid, Use: "sig", Alg: key.Method.Alg()}
+		switch pub := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		jwks = append(jwks, jwk)
+	}
+	return jwks, nil
+}
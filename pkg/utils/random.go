@@ -1,7 +1,11 @@
 package utils
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"math/big"
 )
 
@@ -14,3 +18,42 @@ func GenerateRandomNumericString(length int) string {
 	}
 	return string(result)
 }
+
+// GenerateRandomToken returns a URL-safe random token built from n random bytes.
+func GenerateRandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken returns the hex encoded SHA-256 of a token, so that tokens can be
+// looked up without being stored in plaintext.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// HashTokenWithKey returns the hex encoded HMAC-SHA256 of a token under a
+// server-side key. Use it for short tokens, whose plain hashes could be
+// reversed by trying every value.
+func HashTokenWithKey(key []byte, token string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// GenerateRandomString returns a random string of length characters drawn
+// from charset.
+func GenerateRandomString(length int, charset string) (string, error) {
+	result := make([]byte, length)
+	for i := range result {
+		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		if err != nil {
+			return "", err
+		}
+		result[i] = charset[num.Int64()]
+	}
+	return string(result), nil
+}